@@ -0,0 +1,604 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+const (
+	// ExtensionAtomic is the URI of the Atomic Operations extension, used in
+	// the "ext" media type parameter.
+	//
+	// https://jsonapi.org/ext/atomic/
+	ExtensionAtomic = "https://jsonapi.org/ext/atomic"
+
+	// OpAdd is the code of an operation adding a resource, or members to a
+	// to-many relationship
+	OpAdd = "add"
+	// OpUpdate is the code of an operation updating a resource, or replacing
+	// the members of a relationship
+	OpUpdate = "update"
+	// OpRemove is the code of an operation removing a resource, or members from
+	// a to-many relationship
+	OpRemove = "remove"
+
+	atomicOperationsPointer = "/atomic:operations"
+)
+
+var (
+	// ErrInvalidOperation is returned when an operation's code is not one of
+	// OpAdd, OpUpdate or OpRemove, or it lacks the members its code requires.
+	ErrInvalidOperation = errors.New("jsonapi: invalid atomic operation")
+	// ErrUnknownLID is returned when an operation refers to a local identifier
+	// that was not assigned by a preceding "add" operation.
+	ErrUnknownLID = errors.New("jsonapi: unknown local identifier")
+)
+
+// OperationsPayload is used to represent an Atomic Operations request
+// document.
+type OperationsPayload struct {
+	Operations []*Operation `json:"atomic:operations"`
+}
+
+// ResultsPayload is used to represent an Atomic Operations response document.
+type ResultsPayload struct {
+	Results []*Result `json:"atomic:results"`
+}
+
+func (p *ResultsPayload) clearIncluded() {}
+
+// Operation is used to represent an operation of an Atomic Operations request.
+// Data holds nil, a *Node or a []*Node: the resource object of a resource
+// operation, or the resource linkage of a relationship operation.
+type Operation struct {
+	Op   string        `json:"op"`
+	Ref  *OperationRef `json:"ref,omitempty"`
+	Href string        `json:"href,omitempty"`
+	Data interface{}   `json:"data,omitempty"`
+	Meta *Meta         `json:"meta,omitempty"`
+}
+
+// OperationRef is used to represent the target of an operation: a resource,
+// identified by its id or local identifier, or one of its relationships.
+type OperationRef struct {
+	Type         string `json:"type"`
+	ID           string `json:"id,omitempty"`
+	LID          string `json:"lid,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+}
+
+// Result is used to represent the result of an operation. Data is the
+// resource added or updated by a resource operation, and nil otherwise.
+type Result struct {
+	Data *Node `json:"data,omitempty"`
+	Meta *Meta `json:"meta,omitempty"`
+}
+
+// operation is Operation without its JSON methods.
+type operation Operation
+
+// MarshalJSON implements json.Marshaler; relationship operations always have
+// "data", which is null to clear a to-one relationship.
+func (op *Operation) MarshalJSON() ([]byte, error) {
+	if op.Data == nil && op.isRelationshipOperation() {
+		return json.Marshal(struct {
+			*operation
+			Data interface{} `json:"data"`
+		}{operation: (*operation)(op)})
+	}
+
+	return json.Marshal((*operation)(op))
+}
+
+// UnmarshalJSON implements json.Unmarshaler; "data" is decoded into a *Node
+// or a []*Node depending on its shape.
+func (op *Operation) UnmarshalJSON(data []byte) error {
+	aux := struct {
+		*operation
+		Data json.RawMessage `json:"data"`
+	}{operation: (*operation)(op)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	op.Data = nil
+	switch data := bytes.TrimSpace(aux.Data); {
+	case len(data) == 0 || bytes.Equal(data, jsonNull):
+	case data[0] == '[':
+		nodes := []*Node{}
+		if err := json.Unmarshal(data, &nodes); err != nil {
+			return err
+		}
+		op.Data = nodes
+	default:
+		node := new(Node)
+		if err := json.Unmarshal(data, node); err != nil {
+			return err
+		}
+		op.Data = node
+	}
+
+	return nil
+}
+
+func (op *Operation) isRelationshipOperation() bool {
+	return op.Ref != nil && op.Ref.Relationship != ""
+}
+
+// NewOperation returns an operation adding, updating or removing model, a
+// struct pointer, depending on op. The relationships of added and updated
+// resources are sent as resource linkage. ErrUnexpectedType is returned if
+// model is not a non-nil struct pointer.
+func NewOperation(op string, model interface{}) (*Operation, error) {
+	if v := reflect.ValueOf(model); v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+
+	node, err := visitModelNode(model, newIncludedNodes(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case OpAdd, OpUpdate:
+		return &Operation{Op: op, Data: node}, nil
+	case OpRemove:
+		return &Operation{Op: op, Ref: &OperationRef{Type: node.Type, ID: node.ID, LID: node.LID}}, nil
+	}
+
+	return nil, ErrInvalidOperation
+}
+
+// NewRelationshipOperation returns an operation on the relation named
+// relation of model, a struct pointer, whose current members are sent as
+// resource linkage: OpUpdate replaces the members of the relationship, while
+// OpAdd and OpRemove add or remove them from a to-many relationship.
+func NewRelationshipOperation(op string, model interface{}, relation string) (*Operation, error) {
+	if op != OpAdd && op != OpUpdate && op != OpRemove {
+		return nil, ErrInvalidOperation
+	}
+
+	payload, err := MarshalRelationship(model, relation)
+	if err != nil {
+		return nil, err
+	}
+	node, err := visitModelNode(model, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &Operation{
+		Op:  op,
+		Ref: &OperationRef{Type: node.Type, ID: node.ID, LID: node.LID, Relationship: relation},
+	}
+	switch rel := payload.(type) {
+	case *RelationshipManyNode:
+		o.Data = rel.Data
+	case *RelationshipOneNode:
+		if op != OpUpdate {
+			return nil, ErrNotToManyRelationship
+		}
+		if rel.Data != nil {
+			o.Data = rel.Data
+		}
+	}
+
+	return o, nil
+}
+
+// MarshalOperations writes an Atomic Operations request document; it should
+// be sent with the Content-Type
+//
+//	application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"
+func MarshalOperations(w io.Writer, operations []*Operation) error {
+	return json.NewEncoder(w).Encode(&OperationsPayload{Operations: operations})
+}
+
+// UnmarshalOperations reads the operations of an Atomic Operations request
+// document.
+func UnmarshalOperations(in io.Reader) ([]*Operation, error) {
+	payload := new(OperationsPayload)
+	if err := json.NewDecoder(in).Decode(payload); err != nil {
+		return nil, err
+	}
+	if payload.Operations == nil {
+		return nil, ErrInvalidOperation
+	}
+
+	return payload.Operations, nil
+}
+
+// UnmarshalResults reads the results of an Atomic Operations response
+// document. The resource of the i-th result, if any, is unmarshalled into
+// models[i] so that e.g. server-generated IDs are set on the models the
+// operations were built from; nil models are skipped.
+func UnmarshalResults(in io.Reader, models ...interface{}) ([]*Result, error) {
+	payload := new(ResultsPayload)
+	if err := json.NewDecoder(in).Decode(payload); err != nil {
+		return nil, err
+	}
+
+	for i, result := range payload.Results {
+		if i >= len(models) || models[i] == nil || result.Data == nil {
+			continue
+		}
+		if err := unmarshalNode(result.Data, reflect.ValueOf(models[i]), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return payload.Results, nil
+}
+
+// OperationFunc applies a single operation to the data store. model is a new
+// struct pointer of the target's type: it is populated from the resource
+// object of "add" and "update" operations, and has its primary field set from
+// the ref of "remove" and relationship operations, whose relation field holds
+// the members of the resource linkage.
+//
+// The resource added or updated by a resource operation is reported in the
+// results from model after the function returns, e.g. with an ID assigned.
+type OperationFunc func(ctx context.Context, op *Operation, model interface{}) error
+
+// OperationError is returned by Dispatcher.Dispatch when an operation fails.
+type OperationError struct {
+	// Index is the position of the failed operation in the request
+	Index int
+	Err   error
+}
+
+// Error implements the `Error` interface.
+func (e *OperationError) Error() string {
+	return fmt.Sprintf("jsonapi: operation %d: %s", e.Index, e.Err.Error())
+}
+
+// Unwrap returns the error of the failed operation.
+func (e *OperationError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorObject returns the error of the failed operation as an error object
+// whose source points at the operation. *ErrorObject errors are copied, other
+// errors are reported as 500 Internal Server Error, or as 400 Bad Request
+// when the operation itself is invalid.
+func (e *OperationError) ErrorObject() *ErrorObject {
+	obj := &ErrorObject{}
+	var errObj *ErrorObject
+	switch {
+	case errors.As(e.Err, &errObj):
+		*obj = *errObj
+	case errors.Is(e.Err, ErrInvalidOperation), errors.Is(e.Err, ErrUnknownLID),
+		errors.Is(e.Err, ErrUnknownRelationship), errors.Is(e.Err, ErrInvalidLinkage),
+		errors.Is(e.Err, ErrLinkageTypeMismatch):
+		obj.Title = http.StatusText(http.StatusBadRequest)
+		obj.Detail = e.Err.Error()
+		obj.Status = strconv.Itoa(http.StatusBadRequest)
+	default:
+		obj.Title = http.StatusText(http.StatusInternalServerError)
+		obj.Detail = e.Err.Error()
+		obj.Status = strconv.Itoa(http.StatusInternalServerError)
+	}
+	obj.Source = &ErrorSource{Pointer: fmt.Sprintf("%s/%d", atomicOperationsPointer, e.Index)}
+
+	return obj
+}
+
+// Dispatcher applies the operations of Atomic Operations requests in order,
+// with the OperationFunc registered for the type of each operation's target.
+// It is an http.Handler for the endpoint operations are posted to.
+//
+// Local identifiers assigned by "add" operations are resolved to the IDs of
+// the added resources in the refs and resource linkage of later operations.
+type Dispatcher struct {
+	// Transaction, if set, is called with a function applying all operations
+	// of a request; it should run it in a transaction, committed if it
+	// returns nil and rolled back otherwise, so that the operations are
+	// applied atomically.
+	Transaction func(ctx context.Context, apply func(ctx context.Context) error) error
+
+	handlers map[string]*operationHandler
+}
+
+type operationHandler struct {
+	model reflect.Type
+	apply OperationFunc
+}
+
+// NewDispatcher creates a Dispatcher without any registered types.
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{handlers: map[string]*operationHandler{}}
+}
+
+// Handle registers fn to apply the operations targeting the resource type of
+// model, a struct pointer with jsonapi tags.
+func (d *Dispatcher) Handle(model interface{}, fn OperationFunc) error {
+	if err := ValidateModel(model); err != nil {
+		return err
+	}
+	name, err := ResourceType(model)
+	if err != nil {
+		return err
+	}
+	t, err := modelType(model)
+	if err != nil {
+		return err
+	}
+
+	d.handlers[name] = &operationHandler{model: t, apply: fn}
+	return nil
+}
+
+// Dispatch applies operations in order and returns their results. It stops
+// at the first operation that fails and returns an *OperationError, after the
+// Transaction has been rolled back.
+func (d *Dispatcher) Dispatch(ctx context.Context, operations []*Operation) ([]*Result, error) {
+	var results []*Result
+	apply := func(ctx context.Context) error {
+		results = make([]*Result, 0, len(operations))
+		lids := map[string]string{}
+
+		for i, op := range operations {
+			result, err := d.apply(ctx, op, lids)
+			if err != nil {
+				return &OperationError{Index: i, Err: err}
+			}
+			results = append(results, result)
+		}
+		return nil
+	}
+
+	var err error
+	if d.Transaction != nil {
+		err = d.Transaction(ctx, apply)
+	} else {
+		err = apply(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (d *Dispatcher) apply(ctx context.Context, op *Operation, lids map[string]string) (*Result, error) {
+	if op.Op != OpAdd && op.Op != OpUpdate && op.Op != OpRemove {
+		return nil, ErrInvalidOperation
+	}
+	if err := resolveOperationLIDs(op, lids); err != nil {
+		return nil, err
+	}
+
+	var target *Node
+	node, isNode := op.Data.(*Node)
+	switch {
+	case op.isRelationshipOperation() || op.Op == OpRemove:
+		if op.Ref == nil || op.Ref.ID == "" {
+			return nil, ErrInvalidOperation
+		}
+		target = &Node{Type: op.Ref.Type, ID: op.Ref.ID}
+	case isNode && node != nil:
+		target = node
+	default:
+		return nil, ErrInvalidOperation
+	}
+
+	h, ok := d.handlers[target.Type]
+	if !ok {
+		return nil, &ErrorObject{
+			Title:  http.StatusText(http.StatusNotFound),
+			Detail: fmt.Sprintf("Resources of type %q are not supported", target.Type),
+			Status: strconv.Itoa(http.StatusNotFound),
+		}
+	}
+
+	model := reflect.New(h.model)
+	if err := unmarshalNode(target, model, nil); err != nil {
+		return nil, err
+	}
+	if op.isRelationshipOperation() {
+		linkage, err := json.Marshal(&struct {
+			Data interface{} `json:"data"`
+		}{op.Data})
+		if err != nil {
+			return nil, err
+		}
+		if err := UnmarshalRelationship(bytes.NewReader(linkage), model.Interface(), op.Ref.Relationship); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := h.apply(ctx, op, model.Interface()); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	if op.isRelationshipOperation() || op.Op == OpRemove {
+		return result, nil
+	}
+
+	data, err := visitModelNode(model.Interface(), newIncludedNodes(), true)
+	if err != nil {
+		return nil, err
+	}
+	result.Data = data
+	if op.Op == OpAdd && node.LID != "" {
+		lids[lidKey(node.Type, node.LID)] = result.Data.ID
+		result.Data.LID = node.LID
+	}
+
+	return result, nil
+}
+
+// ServeHTTP implements http.Handler: the operations of the request document
+// are dispatched and the results written with the Atomic Operations media
+// type, or 204 No Content if no operation has a result. Requests not sent
+// with the extension are answered with 415 Unsupported Media Type.
+func (d *Dispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	negotiator := &ContentNegotiator{Extensions: []string{ExtensionAtomic}}
+	negotiator.Handler(http.HandlerFunc(d.serveOperations)).ServeHTTP(w, r)
+}
+
+func (d *Dispatcher) serveOperations(w http.ResponseWriter, r *http.Request) {
+	if !containsString(NegotiationFromRequest(r).Request.Extensions, ExtensionAtomic) {
+		writeNegotiationError(w, http.StatusUnsupportedMediaType,
+			"Unsupported Media Type",
+			fmt.Sprintf("Operations must be sent with the %q extension", ExtensionAtomic),
+		)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		WriteErrors(w, http.StatusMethodNotAllowed, []*ErrorObject{{
+			Title:  http.StatusText(http.StatusMethodNotAllowed),
+			Status: strconv.Itoa(http.StatusMethodNotAllowed),
+		}})
+		return
+	}
+
+	operations, err := UnmarshalOperations(r.Body)
+	if err != nil {
+		WriteErrors(w, http.StatusBadRequest, []*ErrorObject{{
+			Title:  http.StatusText(http.StatusBadRequest),
+			Detail: err.Error(),
+			Status: strconv.Itoa(http.StatusBadRequest),
+			Source: &ErrorSource{Pointer: atomicOperationsPointer},
+		}})
+		return
+	}
+
+	results, err := d.Dispatch(r.Context(), operations)
+	if err != nil {
+		var opErr *OperationError
+		if !errors.As(err, &opErr) {
+			writeInternalError(w, err)
+			return
+		}
+		WriteErrors(w, 0, []*ErrorObject{opErr.ErrorObject()})
+		return
+	}
+
+	for _, result := range results {
+		if result.Data != nil || result.Meta != nil {
+			w.Header().Set(headerContentType, MediaTypeParams{Extensions: []string{ExtensionAtomic}}.String())
+			writePayload(w, http.StatusOK, &ResultsPayload{Results: results}, nil)
+			return
+		}
+	}
+
+	WriteNoContent(w)
+}
+
+// resolveOperationLIDs replaces the local identifiers in the ref and resource
+// linkage of op with the IDs assigned by preceding operations.
+func resolveOperationLIDs(op *Operation, lids map[string]string) error {
+	if op.Ref != nil && op.Ref.ID == "" && op.Ref.LID != "" {
+		id, ok := lids[lidKey(op.Ref.Type, op.Ref.LID)]
+		if !ok {
+			return ErrUnknownLID
+		}
+		op.Ref.ID = id
+	}
+
+	switch data := op.Data.(type) {
+	case *Node:
+		if data == nil {
+			return nil
+		}
+		if op.Op != OpAdd {
+			if err := resolveNodeLID(data, lids); err != nil {
+				return err
+			}
+		}
+		if op.isRelationshipOperation() {
+			return nil
+		}
+		for _, rel := range data.Relationships {
+			if err := resolveLinkageLIDs(rel, lids); err != nil {
+				return err
+			}
+		}
+	case []*Node:
+		for _, n := range data {
+			if err := resolveNodeLID(n, lids); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveLinkageLIDs resolves the local identifiers of the resource linkage
+// of a relationship, either built by visitModelNode or decoded from JSON.
+func resolveLinkageLIDs(relationship interface{}, lids map[string]string) error {
+	switch rel := relationship.(type) {
+	case *RelationshipOneNode:
+		if rel.Data != nil {
+			return resolveNodeLID(rel.Data, lids)
+		}
+	case *RelationshipManyNode:
+		for _, n := range rel.Data {
+			if err := resolveNodeLID(n, lids); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		switch data := rel["data"].(type) {
+		case map[string]interface{}:
+			return resolveIdentifierLID(data, lids)
+		case []interface{}:
+			for _, d := range data {
+				if identifier, ok := d.(map[string]interface{}); ok {
+					if err := resolveIdentifierLID(identifier, lids); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func resolveNodeLID(n *Node, lids map[string]string) error {
+	if n == nil || n.ID != "" || n.LID == "" {
+		return nil
+	}
+	id, ok := lids[lidKey(n.Type, n.LID)]
+	if !ok {
+		return ErrUnknownLID
+	}
+	n.ID = id
+
+	return nil
+}
+
+func resolveIdentifierLID(identifier map[string]interface{}, lids map[string]string) error {
+	if id, _ := identifier["id"].(string); id != "" {
+		return nil
+	}
+	lid, _ := identifier["lid"].(string)
+	if lid == "" {
+		return nil
+	}
+
+	t, _ := identifier["type"].(string)
+	id, ok := lids[lidKey(t, lid)]
+	if !ok {
+		return ErrUnknownLID
+	}
+	identifier["id"] = id
+
+	return nil
+}
+
+func lidKey(t, lid string) string {
+	return fmt.Sprintf("%s,%s", t, lid)
+}
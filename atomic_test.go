@@ -0,0 +1,306 @@
+package jsonapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const testOperations = `{
+	"atomic:operations": [{
+		"op": "add",
+		"data": {
+			"type": "posts",
+			"lid": "new-post",
+			"attributes": {"title": "Atomic"}
+		}
+	}, {
+		"op": "add",
+		"data": {
+			"type": "comments",
+			"attributes": {"body": "First"}
+		}
+	}, {
+		"op": "update",
+		"ref": {"type": "posts", "lid": "new-post", "relationship": "latest_comment"},
+		"data": {"type": "comments", "id": "1"}
+	}, {
+		"op": "remove",
+		"ref": {"type": "posts", "id": "7"}
+	}]
+}`
+
+// testStore is an in-memory store for posts and comments whose operations
+// are recorded.
+type testStore struct {
+	nextID  int
+	applied []string
+}
+
+func (s *testStore) dispatcher(t *testing.T) *Dispatcher {
+	d := NewDispatcher()
+	if err := d.Handle(new(Post), func(ctx context.Context, op *Operation, model interface{}) error {
+		post := model.(*Post)
+		if op.Op == OpAdd {
+			s.nextID++
+			post.ID = uint64(s.nextID)
+		}
+		if post.LatestComment != nil {
+			s.applied = append(s.applied, op.Op+" posts/"+strconv.FormatUint(post.ID, 10)+" latest_comment=comments/"+strconv.Itoa(post.LatestComment.ID))
+			return nil
+		}
+		s.applied = append(s.applied, op.Op+" posts/"+strconv.FormatUint(post.ID, 10))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Handle(new(Comment), func(ctx context.Context, op *Operation, model interface{}) error {
+		comment := model.(*Comment)
+		if op.Op == OpAdd {
+			s.nextID++
+			comment.ID = s.nextID
+		}
+		s.applied = append(s.applied, op.Op+" comments/"+strconv.Itoa(comment.ID))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestUnmarshalOperations(t *testing.T) {
+	ops, err := UnmarshalOperations(strings.NewReader(testOperations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 4 {
+		t.Fatalf("got %d operations, want 4", len(ops))
+	}
+
+	node, ok := ops[0].Data.(*Node)
+	if !ok || node.Type != "posts" || node.LID != "new-post" {
+		t.Fatalf("got data %#v, want the new post", ops[0].Data)
+	}
+	if ops[2].Ref.Relationship != "latest_comment" || ops[2].Data.(*Node).ID != "1" {
+		t.Fatalf("got %+v, want the latest_comment relationship operation", ops[2])
+	}
+	if ops[3].Data != nil {
+		t.Fatalf("got data %v, want none", ops[3].Data)
+	}
+}
+
+func TestUnmarshalOperations_missingOperations(t *testing.T) {
+	_, err := UnmarshalOperations(strings.NewReader(`{"data": null}`))
+	if err != ErrInvalidOperation {
+		t.Fatalf("got %v, want %v", err, ErrInvalidOperation)
+	}
+}
+
+func TestMarshalOperations(t *testing.T) {
+	add, err := NewOperation(OpAdd, &Post{Title: "New", LatestComment: &Comment{ID: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unset, err := NewRelationshipOperation(OpUpdate, &Post{ID: 2}, "latest_comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remove, err := NewOperation(OpRemove, &Post{ID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalOperations(out, []*Operation{add, unset, remove}); err != nil {
+		t.Fatal(err)
+	}
+
+	var document struct {
+		Operations []map[string]interface{} `json:"atomic:operations"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Operations) != 3 {
+		t.Fatalf("got %d operations, want 3", len(document.Operations))
+	}
+	if data, ok := document.Operations[1]["data"]; !ok || data != nil {
+		t.Fatalf("got %v, want explicit null data to clear the relationship", document.Operations[1])
+	}
+	if _, ok := document.Operations[2]["data"]; ok {
+		t.Fatalf("got %v, want no data for a remove operation", document.Operations[2])
+	}
+	ref := document.Operations[2]["ref"].(map[string]interface{})
+	if ref["type"] != "posts" || ref["id"] != "2" {
+		t.Fatalf("got ref %v, want posts/2", ref)
+	}
+
+	ops, err := UnmarshalOperations(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node := ops[0].Data.(*Node); node.Relationships["latest_comment"] == nil {
+		t.Fatalf("got %+v, want the latest_comment linkage", node)
+	}
+}
+
+func TestNewOperation_unexpectedType(t *testing.T) {
+	for _, model := range []interface{}{Post{ID: 1}, (*Post)(nil), "post", nil} {
+		if _, err := NewOperation(OpAdd, model); err != ErrUnexpectedType {
+			t.Fatalf("%#v: got %v, want %v", model, err, ErrUnexpectedType)
+		}
+	}
+}
+
+func TestNewRelationshipOperation_toOneAdd(t *testing.T) {
+	_, err := NewRelationshipOperation(OpAdd, &Post{ID: 2}, "latest_comment")
+	if err != ErrNotToManyRelationship {
+		t.Fatalf("got %v, want %v", err, ErrNotToManyRelationship)
+	}
+}
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	store := &testStore{}
+	ops, err := UnmarshalOperations(strings.NewReader(testOperations))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := store.dispatcher(t).Dispatch(context.Background(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"add posts/1",
+		"add comments/2",
+		"update posts/1 latest_comment=comments/1",
+		"remove posts/7",
+	}
+	if strings.Join(store.applied, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got operations %q, want %q", store.applied, want)
+	}
+
+	if len(results) != 4 {
+		t.Fatalf("got %d results, want 4", len(results))
+	}
+	if results[0].Data.ID != "1" || results[0].Data.LID != "new-post" {
+		t.Fatalf("got %+v, want the added post with its lid", results[0].Data)
+	}
+	if results[2].Data != nil || results[3].Data != nil {
+		t.Fatal("Expected empty results for relationship and remove operations")
+	}
+}
+
+func TestDispatcher_DispatchUnknownLID(t *testing.T) {
+	store := &testStore{}
+	ops := []*Operation{{Op: OpRemove, Ref: &OperationRef{Type: "posts", LID: "missing"}}}
+
+	_, err := store.dispatcher(t).Dispatch(context.Background(), ops)
+	var opErr *OperationError
+	if !errors.As(err, &opErr) || opErr.Index != 0 || !errors.Is(err, ErrUnknownLID) {
+		t.Fatalf("got %v, want an operation error for %v", err, ErrUnknownLID)
+	}
+	if obj := opErr.ErrorObject(); obj.Status != "400" || obj.Source.Pointer != "/atomic:operations/0" {
+		t.Fatalf("got %+v, want a 400 pointing at the operation", obj)
+	}
+}
+
+func TestDispatcher_DispatchNilData(t *testing.T) {
+	store := &testStore{}
+	for _, op := range []string{OpAdd, OpUpdate} {
+		ops := []*Operation{{Op: op, Data: (*Node)(nil)}}
+
+		_, err := store.dispatcher(t).Dispatch(context.Background(), ops)
+		if !errors.Is(err, ErrInvalidOperation) {
+			t.Errorf("%s: got %v, want %v", op, err, ErrInvalidOperation)
+		}
+	}
+}
+
+func TestDispatcher_DispatchRollback(t *testing.T) {
+	store := &testStore{}
+	d := store.dispatcher(t)
+	var rolledBack bool
+	d.Transaction = func(ctx context.Context, apply func(context.Context) error) error {
+		err := apply(ctx)
+		rolledBack = err != nil
+		return err
+	}
+
+	ops := []*Operation{
+		{Op: OpAdd, Data: &Node{Type: "comments"}},
+		{Op: OpAdd, Data: &Node{Type: "unknown"}},
+	}
+	_, err := d.Dispatch(context.Background(), ops)
+	var opErr *OperationError
+	if !errors.As(err, &opErr) || opErr.Index != 1 {
+		t.Fatalf("got %v, want an error for the second operation", err)
+	}
+	if !rolledBack {
+		t.Fatal("Expected the transaction to be rolled back")
+	}
+	if obj := opErr.ErrorObject(); obj.Status != "404" {
+		t.Fatalf("got status %s, want 404", obj.Status)
+	}
+}
+
+func TestDispatcher_ServeHTTP(t *testing.T) {
+	store := &testStore{}
+	mediaType := MediaTypeParams{Extensions: []string{ExtensionAtomic}}.String()
+
+	req := httptest.NewRequest(http.MethodPost, "/operations", strings.NewReader(testOperations))
+	req.Header.Set(headerContentType, mediaType)
+	req.Header.Set(headerAccept, mediaType)
+	rr := httptest.NewRecorder()
+	store.dispatcher(t).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, want %d: %s", rr.Code, http.StatusOK, rr.Body)
+	}
+	if got := rr.Header().Get(headerContentType); got != mediaType {
+		t.Fatalf("got Content-Type %q, want %q", got, mediaType)
+	}
+
+	results, err := UnmarshalResults(rr.Body, new(Post))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || results[1].Data.Type != "comments" {
+		t.Fatalf("got %v, want 4 results", results)
+	}
+}
+
+func TestDispatcher_ServeHTTPWithoutExtension(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/operations", strings.NewReader(testOperations))
+	req.Header.Set(headerContentType, MediaType)
+	rr := httptest.NewRecorder()
+	(&testStore{}).dispatcher(t).ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("got status %d, want %d", rr.Code, http.StatusUnsupportedMediaType)
+	}
+}
+
+func TestUnmarshalResults(t *testing.T) {
+	in := strings.NewReader(`{"atomic:results": [{"data": {"type": "posts", "id": "5", "attributes": {"title": "Created"}}}, {}]}`)
+	post := &Post{Title: "New"}
+
+	results, err := UnmarshalResults(in, post, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[1].Data != nil {
+		t.Fatalf("got %v, want 2 results", results)
+	}
+	if post.ID != 5 || post.Title != "Created" {
+		t.Fatalf("got %+v, want the created post", post)
+	}
+}
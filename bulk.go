@@ -0,0 +1,173 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BulkPayloadError is returned by UnmarshalBulkPayload when resources of the
+// document could not be unmarshalled. Each error object's source points at
+// the offending member, e.g. "/data/2/attributes/title".
+type BulkPayloadError struct {
+	Errors []*ErrorObject
+}
+
+// Error implements the `Error` interface.
+func (e *BulkPayloadError) Error() string {
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = fmt.Sprintf("%s: %s", obj.Source.Pointer, obj.Detail)
+	}
+	return fmt.Sprintf("jsonapi: invalid bulk payload: %s", strings.Join(details, "; "))
+}
+
+// UnmarshalBulkPayload converts the array of resource objects of a bulk
+// create or update request into struct pointers of type t, which must be a
+// pointer to a struct; ErrUnexpectedType is returned otherwise.
+//
+// Unlike UnmarshalManyPayload, each resource is validated independently:
+// the returned slice always has an element per resource, and when some of
+// them fail to unmarshal a *BulkPayloadError reports all the failures with
+// "/data/N/..." pointers, ready to be written with WriteErrors:
+//
+//	models, err := jsonapi.UnmarshalBulkPayload(r.Body, reflect.TypeOf(new(Blog)))
+//	if bulkErr, ok := err.(*jsonapi.BulkPayloadError); ok {
+//		jsonapi.WriteErrors(w, 0, bulkErr.Errors)
+//		return
+//	}
+//
+// Resources may carry client-generated ids or local identifiers, which must
+// be unique within the document; relationship linkage is resolved against
+// the "included" resources by id or lid.
+func UnmarshalBulkPayload(in io.Reader, t reflect.Type) ([]interface{}, error) {
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+
+	payload := struct {
+		Data     json.RawMessage `json:"data"`
+		Included []*Node         `json:"included"`
+	}{}
+	if err := json.NewDecoder(in).Decode(&payload); err != nil {
+		return nil, err
+	}
+
+	resourceType, err := ResourceType(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []json.RawMessage
+	if !isJSONArray(payload.Data) ||
+		json.Unmarshal(payload.Data, &data) != nil {
+		return nil, &BulkPayloadError{Errors: []*ErrorObject{
+			bulkError(http.StatusBadRequest, "/data", "Primary data must be an array of resource objects"),
+		}}
+	}
+
+	includedMap := map[string]*Node{}
+	for _, included := range payload.Included {
+		includedMap[nodeKey(included)] = included
+	}
+
+	models := make([]interface{}, len(data))
+	errs := []*ErrorObject{}
+	seen := map[string]int{}
+
+	for i, raw := range data {
+		pointer := "/data/" + strconv.Itoa(i)
+		models[i] = reflect.New(t.Elem()).Interface()
+
+		node := new(Node)
+		if err := json.Unmarshal(raw, node); err != nil {
+			errs = append(errs, bulkError(http.StatusBadRequest, pointer, "Invalid resource object"))
+			continue
+		}
+		if node.Type != resourceType {
+			errs = append(errs, bulkError(http.StatusConflict, pointer+"/type",
+				fmt.Sprintf("Resource type %q does not match %q", node.Type, resourceType)))
+			continue
+		}
+
+		if node.ID != "" || node.LID != "" {
+			key := nodeKey(node)
+			if j, duplicate := seen[key]; duplicate {
+				member := "id"
+				if node.ID == "" {
+					member = "lid"
+				}
+				errs = append(errs, bulkError(http.StatusBadRequest, pointer+"/"+member,
+					fmt.Sprintf("Resource is a duplicate of /data/%d", j)))
+				continue
+			}
+			seen[key] = i
+		}
+
+		if err := unmarshalNode(node, reflect.ValueOf(models[i]), &includedMap); err != nil {
+			errs = append(errs, bulkError(http.StatusBadRequest,
+				pointer+bulkErrorMember(node, t, &includedMap), err.Error()))
+		}
+	}
+
+	if len(errs) > 0 {
+		return models, &BulkPayloadError{Errors: errs}
+	}
+
+	return models, nil
+}
+
+// bulkErrorMember locates the member of a resource that failed to unmarshal
+// by unmarshalling its id, attributes and relationships one at a time, and
+// returns its pointer relative to the resource.
+func bulkErrorMember(node *Node, t reflect.Type, included *map[string]*Node) string {
+	fails := func(n *Node) bool {
+		n.Type = node.Type
+		return unmarshalNode(n, reflect.New(t.Elem()), included) != nil
+	}
+
+	if node.ID != "" && fails(&Node{ID: node.ID}) {
+		return "/id"
+	}
+	for _, key := range sortedKeys(node.Attributes) {
+		if fails(&Node{Attributes: map[string]interface{}{key: node.Attributes[key]}}) {
+			return "/attributes/" + escapePointerToken(key)
+		}
+	}
+	for _, key := range sortedKeys(node.Relationships) {
+		if fails(&Node{Relationships: map[string]interface{}{key: node.Relationships[key]}}) {
+			return "/relationships/" + escapePointerToken(key)
+		}
+	}
+
+	return ""
+}
+
+func bulkError(status int, pointer, detail string) *ErrorObject {
+	return &ErrorObject{
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Status: strconv.Itoa(status),
+		Source: &ErrorSource{Pointer: pointer},
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escapePointerToken escapes a member name for use in a JSON Pointer, see
+// RFC 6901.
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
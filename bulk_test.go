@@ -0,0 +1,113 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnmarshalBulkPayload(t *testing.T) {
+	payload := `{
+		"data": [{
+			"type": "posts",
+			"lid": "first",
+			"attributes": {"title": "First"},
+			"relationships": {
+				"comments": {"data": [{"type": "comments", "lid": "comment"}]}
+			}
+		}, {
+			"type": "posts",
+			"id": "2",
+			"attributes": {"title": "Second"}
+		}],
+		"included": [
+			{"type": "comments", "lid": "comment", "attributes": {"body": "Hi"}}
+		]
+	}`
+
+	models, err := UnmarshalBulkPayload(strings.NewReader(payload), reflect.TypeOf(new(Post)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 {
+		t.Fatalf("got %d models, want 2", len(models))
+	}
+
+	first := models[0].(*Post)
+	if first.LID != "first" || first.Title != "First" {
+		t.Fatalf("got %+v, want the first post", first)
+	}
+	if len(first.Comments) != 1 || first.Comments[0].Body != "Hi" {
+		t.Fatalf("got comments %v, want the included comment", first.Comments)
+	}
+	if second := models[1].(*Post); second.ID != 2 || second.Title != "Second" {
+		t.Fatalf("got %+v, want the second post", second)
+	}
+}
+
+func TestUnmarshalBulkPayload_errors(t *testing.T) {
+	payload := `{
+		"data": [
+			{"type": "posts", "id": "1", "attributes": {"title": "Valid"}},
+			{"type": "comments", "id": "2"},
+			{"type": "posts", "id": "3", "attributes": {"title": "Valid", "blog_id": "not a number"}},
+			{"type": "posts", "id": "1"},
+			{"type": "posts", "id": "five"},
+			"not a resource"
+		]
+	}`
+
+	models, err := UnmarshalBulkPayload(strings.NewReader(payload), reflect.TypeOf(new(Post)))
+	bulkErr, ok := err.(*BulkPayloadError)
+	if !ok {
+		t.Fatalf("got %v, want a *BulkPayloadError", err)
+	}
+	if len(models) != 6 || models[0].(*Post).Title != "Valid" {
+		t.Fatalf("got %v, want the valid resources unmarshalled", models)
+	}
+
+	want := map[string]string{
+		"/data/1/type":               "409",
+		"/data/2/attributes/blog_id": "400",
+		"/data/3/id":                 "400",
+		"/data/4/id":                 "400",
+		"/data/5":                    "400",
+	}
+	if len(bulkErr.Errors) != len(want) {
+		t.Fatalf("got %d errors, want %d: %v", len(bulkErr.Errors), len(want), bulkErr)
+	}
+	for _, obj := range bulkErr.Errors {
+		if status, ok := want[obj.Source.Pointer]; !ok || obj.Status != status {
+			t.Errorf("got status %s for %s, want %v", obj.Status, obj.Source.Pointer, want)
+		}
+	}
+}
+
+func TestUnmarshalBulkPayload_notAnArray(t *testing.T) {
+	payload := `{"data": {"type": "posts", "id": "1"}}`
+
+	_, err := UnmarshalBulkPayload(strings.NewReader(payload), reflect.TypeOf(new(Post)))
+	bulkErr, ok := err.(*BulkPayloadError)
+	if !ok {
+		t.Fatalf("got %v, want a *BulkPayloadError", err)
+	}
+	if bulkErr.Errors[0].Source.Pointer != "/data" {
+		t.Fatalf("got pointer %q, want /data", bulkErr.Errors[0].Source.Pointer)
+	}
+}
+
+func TestUnmarshalBulkPayload_unexpectedType(t *testing.T) {
+	payload := `{"data": [{"type": "posts", "id": "1"}]}`
+
+	for _, typ := range []reflect.Type{reflect.TypeOf(Post{}), reflect.TypeOf(new(string)), nil} {
+		if _, err := UnmarshalBulkPayload(strings.NewReader(payload), typ); err != ErrUnexpectedType {
+			t.Errorf("%v: got %v, want %v", typ, err, ErrUnexpectedType)
+		}
+	}
+}
+
+func TestEscapePointerToken(t *testing.T) {
+	if got, want := escapePointerToken("a/b~c"), "a~1b~0c"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
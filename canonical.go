@@ -0,0 +1,170 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Canonicalize rewrites a JSON document into its canonical form, so that
+// documents which only differ in insignificant ways are byte-identical:
+//
+//   - insignificant whitespace is removed
+//   - object members are sorted by name
+//   - numbers are normalized, e.g. 1.50 becomes 1.5 and 1e2 becomes 100
+//   - strings only escape the characters JSON requires
+//   - the top-level "included" array is sorted by type, id and lid, as its
+//     order carries no meaning, like SortIncluded does
+//
+// Integers are kept as written so that large values don't lose precision.
+func Canonicalize(document []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(document))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if doc, ok := v.(map[string]interface{}); ok {
+		if included, ok := doc[memberIncluded].([]interface{}); ok {
+			sortIncludedValues(included)
+		}
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := writeCanonical(out, v); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// MarshalCanonical writes a jsonapi response for one or many records like
+// MarshalPayload, in canonical form, see Canonicalize.
+func MarshalCanonical(w io.Writer, models interface{}) error {
+	payload, err := Marshal(models)
+	if err != nil {
+		return err
+	}
+
+	b, err := canonicalJSON(payload)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+func canonicalJSON(v interface{}) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return Canonicalize(b)
+}
+
+func writeCanonical(out *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		out.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			writeCanonicalString(out, k)
+			out.WriteByte(':')
+			if err := writeCanonical(out, v[k]); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case []interface{}:
+		out.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := writeCanonical(out, e); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	case string:
+		writeCanonicalString(out, v)
+	case json.Number:
+		out.WriteString(canonicalNumber(v))
+	default:
+		// bool and nil
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		out.Write(b)
+	}
+
+	return nil
+}
+
+func writeCanonicalString(out *bytes.Buffer, s string) {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode terminates the value with a newline
+	out.Truncate(out.Len() - 1)
+}
+
+// canonicalNumber normalizes a number literal: integers are kept as is,
+// other numbers are written in their shortest form, as integers if they are
+// integral and can be represented exactly.
+func canonicalNumber(n json.Number) string {
+	s := n.String()
+	if !strings.ContainsAny(s, ".eE") {
+		if s == "-0" {
+			return "0"
+		}
+		return s
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return s
+	}
+	if f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+		return strconv.FormatFloat(f+0, 'f', -1, 64)
+	}
+	if abs := math.Abs(f); abs < 1e-6 || abs >= 1e21 {
+		return strconv.FormatFloat(f, 'e', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// sortIncludedValues sorts decoded resource objects by type, id and lid.
+func sortIncludedValues(included []interface{}) {
+	member := func(v interface{}, name string) string {
+		if obj, ok := v.(map[string]interface{}); ok {
+			s, _ := obj[name].(string)
+			return s
+		}
+		return ""
+	}
+
+	sort.SliceStable(included, func(i, j int) bool {
+		for _, name := range []string{"type", "id", "lid"} {
+			a, b := member(included[i], name), member(included[j], name)
+			if a == b {
+				continue
+			}
+			if name == "id" {
+				return lessID(a, b)
+			}
+			return a < b
+		}
+		return false
+	})
+}
@@ -0,0 +1,105 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	document := `{
+		"included": [
+			{"type": "posts", "id": "10"},
+			{"type": "posts", "id": "2"},
+			{"id": "1", "type": "comments"},
+			{"type": "posts", "id": "1"}
+		],
+		"data": {
+			"type": "blogs", "id": "1",
+			"attributes": {"title": "<Go> & more", "rating": 4.50, "views": 1e2, "big": 12345678901234567890, "zero": -0}
+		}
+	}`
+
+	got, err := Canonicalize([]byte(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"data":{"attributes":{"big":12345678901234567890,"rating":4.5,"title":"<Go> & more","views":100,"zero":0},"id":"1","type":"blogs"},` +
+		`"included":[{"id":"1","type":"comments"},{"id":"1","type":"posts"},{"id":"2","type":"posts"},{"id":"10","type":"posts"}]}`
+	if string(got) != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCanonicalize_mixedIDs(t *testing.T) {
+	ids := []string{"9", "10", "1a", "a", "-1", "01", "1"}
+
+	var want []byte
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		included := []map[string]interface{}{}
+		for _, j := range r.Perm(len(ids)) {
+			included = append(included, map[string]interface{}{"type": "posts", "id": ids[j]})
+		}
+		document, err := json.Marshal(map[string]interface{}{"data": nil, "included": included})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := Canonicalize(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = got
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("got\n%s\nwant\n%s", got, want)
+		}
+	}
+}
+
+func TestCanonicalize_invalidJSON(t *testing.T) {
+	if _, err := Canonicalize([]byte(`{"data":`)); err == nil {
+		t.Fatal("Expected an error")
+	}
+}
+
+func TestCanonicalNumber(t *testing.T) {
+	for in, want := range map[string]string{
+		"10":      "10",
+		"1.0":     "1",
+		"0.10":    "0.1",
+		"-2.5e1":  "-25",
+		"1e300":   "1e+300",
+		"0.5e-10": "5e-11",
+	} {
+		if got := canonicalNumber(json.Number(in)); got != want {
+			t.Errorf("canonicalNumber(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	blog := testIncludedBlog()
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalCanonical(out, blog); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := bytes.NewBuffer(nil)
+	if err := MarshalPayload(payload, blog); err != nil {
+		t.Fatal(err)
+	}
+	canonical, err := Canonicalize(payload.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out.Bytes(), canonical) {
+		t.Fatalf("got\n%s\nwant\n%s", out, canonical)
+	}
+}
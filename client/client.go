@@ -0,0 +1,238 @@
+// Package client consumes remote JSON API services with models annotated with
+// jsonapi struct tags.
+//
+//	c := client.New("https://example.com/api")
+//
+//	blog := new(Blog)
+//	if err := c.Get(ctx, "/blogs/1", blog); err != nil {
+//		if rerr, ok := err.(*client.ResponseError); ok {
+//			// ...inspect rerr.StatusCode and rerr.Errors...
+//		}
+//		return err
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/google/jsonapi"
+)
+
+const (
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
+)
+
+// Client issues requests to a JSON API service.
+type Client struct {
+	// BaseURL is the URL request paths are resolved against, e.g.
+	// "https://example.com/api".
+	BaseURL string
+	// HTTPClient is the client used to send requests; http.DefaultClient is
+	// used when it is nil.
+	HTTPClient *http.Client
+	// Header holds additional headers sent with every request, e.g.
+	// Authorization.
+	Header http.Header
+}
+
+// New creates a Client for the service at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), Header: http.Header{}}
+}
+
+// ResponseError is returned when the service responds with an error status.
+// Errors holds the error objects of the response's errors document, if any.
+type ResponseError struct {
+	StatusCode int
+	Errors     []*jsonapi.ErrorObject
+}
+
+// Error implements the `Error` interface.
+func (e *ResponseError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("client: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = strings.TrimSpace(obj.Error())
+	}
+	return fmt.Sprintf("client: %d %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Get fetches a single resource from path and unmarshals it into model, a
+// struct pointer, see jsonapi.UnmarshalPayload.
+func (c *Client) Get(ctx context.Context, path string, model interface{}) error {
+	body, _, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+
+	return jsonapi.UnmarshalPayload(bytes.NewReader(body), model)
+}
+
+// List fetches a collection from path and unmarshals it into struct pointers
+// of type t, see jsonapi.UnmarshalManyPayload. Only the first page is
+// fetched; use Iterate to follow pagination links.
+func (c *Client) List(ctx context.Context, path string, t reflect.Type) ([]interface{}, error) {
+	models, _, err := c.page(ctx, path, t)
+	return models, err
+}
+
+// Create posts model, a struct pointer, to the collection at path. Unless the
+// service responds with 204 No Content, model is updated from the resource in
+// the response, e.g. with its server-generated ID.
+func (c *Client) Create(ctx context.Context, path string, model interface{}) error {
+	return c.send(ctx, http.MethodPost, path, model)
+}
+
+// Update patches the resource at path with model, a struct pointer. Unless
+// the service responds with 204 No Content, model is updated from the
+// resource in the response.
+func (c *Client) Update(ctx context.Context, path string, model interface{}) error {
+	return c.send(ctx, http.MethodPatch, path, model)
+}
+
+// Delete deletes the resource at path.
+func (c *Client) Delete(ctx context.Context, path string) error {
+	_, _, err := c.do(ctx, http.MethodDelete, path, nil)
+	return err
+}
+
+func (c *Client) send(ctx context.Context, method, path string, model interface{}) error {
+	in := bytes.NewBuffer(nil)
+	if err := jsonapi.MarshalPayloadWithoutIncluded(in, model); err != nil {
+		return err
+	}
+
+	body, status, err := c.do(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusNoContent || len(body) == 0 {
+		return nil
+	}
+
+	return jsonapi.UnmarshalPayload(bytes.NewReader(body), model)
+}
+
+// page fetches a page of a collection and returns its resources along with
+// the URL of the next page, if any.
+func (c *Client) page(ctx context.Context, path string, t reflect.Type) ([]interface{}, string, error) {
+	body, _, err := c.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	models, err := jsonapi.UnmarshalManyPayload(bytes.NewReader(body), t)
+	if err != nil {
+		return nil, "", err
+	}
+
+	document := struct {
+		Links jsonapi.Links `json:"links"`
+	}{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return nil, "", err
+	}
+
+	next := linkHref(document.Links[jsonapi.KeyNextPage])
+	if next == "" {
+		return models, "", nil
+	}
+	next, err = c.resolve(path, next)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return models, next, nil
+}
+
+// do sends a request and returns the response body, or a *ResponseError if
+// the response status is not successful.
+func (c *Client) do(ctx context.Context, method, path string, in io.Reader) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url(path), in)
+	if err != nil {
+		return nil, 0, err
+	}
+	for k, v := range c.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set(headerAccept, jsonapi.MediaType)
+	if in != nil {
+		req.Header.Set(headerContentType, jsonapi.MediaType)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		rerr := &ResponseError{StatusCode: resp.StatusCode}
+		payload := new(jsonapi.ErrorsPayload)
+		if json.Unmarshal(body, payload) == nil {
+			rerr.Errors = payload.Errors
+		}
+		return nil, resp.StatusCode, rerr
+	}
+
+	return body, resp.StatusCode, nil
+}
+
+// url returns the URL of target, a path relative to the base URL or an
+// absolute URL.
+func (c *Client) url(target string) string {
+	if u, err := url.Parse(target); err == nil && u.IsAbs() {
+		return target
+	}
+	return c.BaseURL + target
+}
+
+// resolve resolves a link against the URL of target.
+func (c *Client) resolve(target, link string) (string, error) {
+	base, err := url.Parse(c.url(target))
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(ref).String(), nil
+}
+
+// linkHref returns the URL of a link, which is either a string or a link
+// object.
+func linkHref(link interface{}) string {
+	switch l := link.(type) {
+	case string:
+		return l
+	case map[string]interface{}:
+		href, _ := l["href"].(string)
+		return href
+	}
+	return ""
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+type Article struct {
+	ID    string `jsonapi:"primary,articles"`
+	Title string `jsonapi:"attr,title"`
+}
+
+func testService(t *testing.T, handler http.HandlerFunc) *Client {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get(headerAccept); got != jsonapi.MediaType {
+			t.Errorf("got Accept %q, want %q", got, jsonapi.MediaType)
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(ts.Close)
+
+	return New(ts.URL + "/api")
+}
+
+func TestClient_Get(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/articles/1" {
+			t.Errorf("got %s %s, want GET /api/articles/1", r.Method, r.URL.Path)
+		}
+		jsonapi.WriteResource(w, http.StatusOK, &Article{ID: "1", Title: "Hello"})
+	})
+
+	article := new(Article)
+	if err := c.Get(context.Background(), "/articles/1", article); err != nil {
+		t.Fatal(err)
+	}
+	if article.ID != "1" || article.Title != "Hello" {
+		t.Fatalf("got %+v, want article 1", article)
+	}
+}
+
+func TestClient_List(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		jsonapi.WriteResource(w, http.StatusOK, []*Article{{ID: "1"}, {ID: "2"}})
+	})
+
+	articles, err := c.List(context.Background(), "/articles", reflect.TypeOf(new(Article)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(articles) != 2 || articles[1].(*Article).ID != "2" {
+		t.Fatalf("got %v, want articles 1 and 2", articles)
+	}
+}
+
+func TestClient_Create(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("got method %s, want POST", r.Method)
+		}
+		if got := r.Header.Get(headerContentType); got != jsonapi.MediaType {
+			t.Errorf("got Content-Type %q, want %q", got, jsonapi.MediaType)
+		}
+		article := new(Article)
+		if err := jsonapi.UnmarshalPayload(r.Body, article); err != nil {
+			t.Error(err)
+		}
+		article.ID = "7"
+		jsonapi.WriteResource(w, http.StatusCreated, article)
+	})
+
+	article := &Article{Title: "New"}
+	if err := c.Create(context.Background(), "/articles", article); err != nil {
+		t.Fatal(err)
+	}
+	if article.ID != "7" || article.Title != "New" {
+		t.Fatalf("got %+v, want the created article", article)
+	}
+}
+
+func TestClient_UpdateNoContent(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/api/articles/1" {
+			t.Errorf("got %s %s, want PATCH /api/articles/1", r.Method, r.URL.Path)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var document map[string]interface{}
+		if err := json.Unmarshal(body, &document); err != nil {
+			t.Error(err)
+		}
+		jsonapi.WriteNoContent(w)
+	})
+
+	article := &Article{ID: "1", Title: "Updated"}
+	if err := c.Update(context.Background(), "/articles/1", article); err != nil {
+		t.Fatal(err)
+	}
+	if article.Title != "Updated" {
+		t.Fatalf("got %+v, want the article unchanged", article)
+	}
+}
+
+func TestClient_Delete(t *testing.T) {
+	var deleted bool
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		deleted = r.Method == http.MethodDelete && r.URL.Path == "/api/articles/1"
+		jsonapi.WriteNoContent(w)
+	})
+
+	if err := c.Delete(context.Background(), "/articles/1"); err != nil {
+		t.Fatal(err)
+	}
+	if !deleted {
+		t.Fatal("The article was not deleted")
+	}
+}
+
+func TestClient_errorDocument(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		jsonapi.WriteErrors(w, 0, []*jsonapi.ErrorObject{
+			{Title: "Not Found", Detail: "No article 3", Status: "404"},
+		})
+	})
+
+	err := c.Get(context.Background(), "/articles/3", new(Article))
+	rerr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("got %v, want a *ResponseError", err)
+	}
+	if rerr.StatusCode != http.StatusNotFound {
+		t.Fatalf("got status %d, want %d", rerr.StatusCode, http.StatusNotFound)
+	}
+	if len(rerr.Errors) != 1 || rerr.Errors[0].Detail != "No article 3" {
+		t.Fatalf("got errors %v, want the error document's", rerr.Errors)
+	}
+	if got, want := rerr.Error(), "client: 404 Error: Not Found No article 3"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestClient_errorWithoutDocument(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad gateway", http.StatusBadGateway)
+	})
+
+	err := c.Delete(context.Background(), "/articles/1")
+	rerr, ok := err.(*ResponseError)
+	if !ok {
+		t.Fatalf("got %v, want a *ResponseError", err)
+	}
+	if rerr.StatusCode != http.StatusBadGateway || rerr.Errors != nil {
+		t.Fatalf("got %+v, want a 502 without error objects", rerr)
+	}
+}
+
+func TestClient_Header(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Header.Get("Authorization"), "Bearer token"; got != want {
+			t.Errorf("got Authorization %q, want %q", got, want)
+		}
+		jsonapi.WriteNoContent(w)
+	})
+	c.Header.Set("Authorization", "Bearer token")
+
+	if err := c.Delete(context.Background(), "/articles/1"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"reflect"
+)
+
+// Iterator walks the resources of a paginated collection, fetching the page
+// linked as "next" by the previous one whenever it runs out of resources.
+//
+//	it := c.Iterate(ctx, "/blogs", reflect.TypeOf(new(Blog)))
+//	for it.Next() {
+//		blog := it.Model().(*Blog)
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type Iterator struct {
+	client *Client
+	ctx    context.Context
+	t      reflect.Type
+
+	next string
+	// visited holds the URLs of the pages fetched, so that a link back to
+	// one of them ends the walk instead of looping
+	visited map[string]bool
+	page    []interface{}
+	model   interface{}
+	err     error
+}
+
+// Iterate returns an Iterator over the collection at path, whose resources
+// are unmarshalled into struct pointers of type t.
+func (c *Client) Iterate(ctx context.Context, path string, t reflect.Type) *Iterator {
+	return &Iterator{client: c, ctx: ctx, t: t, next: path, visited: map[string]bool{}}
+}
+
+// Next advances the Iterator to the next resource, which is then available
+// through Model. It returns false once the last page has been walked or an
+// error occurred, see Err.
+func (it *Iterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.next == "" {
+			it.model = nil
+			return false
+		}
+
+		it.visited[it.client.url(it.next)] = true
+		it.page, it.next, it.err = it.client.page(it.ctx, it.next, it.t)
+		if it.visited[it.client.url(it.next)] {
+			// Pages linking back to each other would never end
+			it.next = ""
+		}
+	}
+
+	it.model, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Model returns the current resource, a struct pointer of the Iterator's
+// type.
+func (it *Iterator) Model() interface{} {
+	return it.model
+}
+
+// Err returns the error that stopped the Iterator, if any.
+func (it *Iterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+func TestIterator(t *testing.T) {
+	var serverURL string
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		articles := []*Article{}
+		links := jsonapi.Links{}
+		switch r.URL.Query().Get(jsonapi.QueryParamPageNumber) {
+		case "":
+			// relative link
+			articles = []*Article{{ID: "1"}, {ID: "2"}}
+			links[jsonapi.KeyNextPage] = "articles?page%5Bnumber%5D=2"
+		case "2":
+			// empty page, absolute link object
+			links[jsonapi.KeyNextPage] = jsonapi.Link{Href: serverURL + "/api/articles?page%5Bnumber%5D=3"}
+		case "3":
+			articles = []*Article{{ID: "3"}}
+		}
+
+		payload, err := jsonapi.Marshal(articles)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		payload.(*jsonapi.ManyPayload).Links = &links
+		jsonapi.WritePayload(w, http.StatusOK, payload)
+	})
+	serverURL = c.BaseURL[:len(c.BaseURL)-len("/api")]
+
+	it := c.Iterate(context.Background(), "/articles", reflect.TypeOf(new(Article)))
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Model().(*Article).ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ids, []string{"1", "2", "3"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got ids %v, want %v", got, want)
+	}
+	if it.Model() != nil {
+		t.Fatal("Expected no current model once the iterator is done")
+	}
+}
+
+func TestIterator_error(t *testing.T) {
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		jsonapi.WriteErrors(w, http.StatusInternalServerError, []*jsonapi.ErrorObject{{Status: "500"}})
+	})
+
+	it := c.Iterate(context.Background(), "/articles", reflect.TypeOf(new(Article)))
+	if it.Next() {
+		t.Fatal("Expected the iterator to stop")
+	}
+	if _, ok := it.Err().(*ResponseError); !ok {
+		t.Fatalf("got %v, want a *ResponseError", it.Err())
+	}
+}
+
+func TestIterator_loop(t *testing.T) {
+	var serverURL string
+	c := testService(t, func(w http.ResponseWriter, r *http.Request) {
+		links := jsonapi.Links{}
+		articles := []*Article{}
+		switch r.URL.Query().Get(jsonapi.QueryParamPageNumber) {
+		case "":
+			articles = []*Article{{ID: "1"}}
+			links[jsonapi.KeyNextPage] = "articles?page%5Bnumber%5D=2"
+		case "2":
+			// back to the first page, as an absolute URL
+			articles = []*Article{{ID: "2"}}
+			links[jsonapi.KeyNextPage] = serverURL + "/api/articles"
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+
+		payload, err := jsonapi.Marshal(articles)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		payload.(*jsonapi.ManyPayload).Links = &links
+		jsonapi.WritePayload(w, http.StatusOK, payload)
+	})
+	serverURL = c.BaseURL[:len(c.BaseURL)-len("/api")]
+
+	it := c.Iterate(context.Background(), "/articles", reflect.TypeOf(new(Article)))
+	var ids []string
+	for it.Next() && len(ids) < 10 {
+		ids = append(ids, it.Model().(*Article).ID)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := ids, []string{"1", "2"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got ids %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,664 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The import path of the jsonapi package and its struct tag annotations
+const (
+	jsonapiPath = "github.com/google/jsonapi"
+
+	annotationJSONAPI   = "jsonapi"
+	annotationPrimary   = "primary"
+	annotationClientID  = "client-id"
+	annotationLID       = "lid"
+	annotationAttribute = "attr"
+	annotationRelation  = "relation"
+	annotationOmitEmpty = "omitempty"
+	annotationISO8601   = "iso8601"
+	annotationRFC3339   = "rfc3339"
+	annotationSeperator = ","
+
+	iso8601TimeFormat = "2006-01-02T15:04:05Z"
+)
+
+// model is a struct with jsonapi struct tags.
+type model struct {
+	name    string
+	fields  []*field
+	primary *field
+	lid     *field
+}
+
+// field is a struct field with a jsonapi struct tag.
+type field struct {
+	name       string
+	typ        types.Type
+	annotation string
+	member     string
+	omitEmpty  bool
+	iso8601    bool
+	rfc3339    bool
+}
+
+type generator struct {
+	pkg     *types.Package
+	imports map[string]bool
+	out     *bytes.Buffer
+}
+
+// generate returns the source of the MarshalJSONAPI and UnmarshalJSONAPI
+// methods of the named models of the package matched by pattern, including
+// its _test.go files if tests is set.
+func generate(pattern string, names []string, tests bool) ([]byte, error) {
+	pkg, err := load(pattern, tests)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, imports: map[string]bool{}, out: bytes.NewBuffer(nil)}
+	for _, name := range names {
+		m, err := g.model(strings.TrimSpace(name))
+		if err != nil {
+			return nil, err
+		}
+		g.marshal(m)
+		g.unmarshal(m)
+	}
+
+	out := bytes.NewBuffer(nil)
+	fmt.Fprintf(out, "// Code generated by jsonapi-codegen. DO NOT EDIT.\n\npackage %s\n\n", pkg.Name())
+	writeImports(out, g.imports)
+	out.Write(g.out.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+// listedPackage is a package listed by go list.
+type listedPackage struct {
+	ImportPath  string
+	Dir         string
+	Export      string
+	ForTest     string
+	DepOnly     bool
+	GoFiles     []string
+	TestGoFiles []string
+}
+
+// load returns the types of the package matched by pattern, including its
+// _test.go files if tests is set. Its dependencies are imported from the
+// export data of the go command. Type errors are ignored, as the package may
+// not build until its methods are generated.
+func load(pattern string, tests bool) (*types.Package, error) {
+	args := []string{"list", "-e", "-export", "-deps", "-json=ImportPath,Dir,Export,ForTest,DepOnly,GoFiles,TestGoFiles"}
+	if tests {
+		args = append(args, "-test")
+	}
+	out, err := exec.Command("go", append(args, pattern)...).Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("jsonapi-codegen: %s", bytes.TrimSpace(exit.Stderr))
+		}
+		return nil, err
+	}
+
+	var target *listedPackage
+	exports := map[string]string{}
+	for dec := json.NewDecoder(bytes.NewReader(out)); dec.More(); {
+		p := new(listedPackage)
+		if err := dec.Decode(p); err != nil {
+			return nil, err
+		}
+		if p.Export != "" && p.ForTest == "" {
+			exports[p.ImportPath] = p.Export
+		}
+		if p.DepOnly || p.ForTest != "" || strings.HasSuffix(p.ImportPath, ".test") {
+			continue
+		}
+		if target != nil {
+			return nil, fmt.Errorf("jsonapi-codegen: %s matches several packages", pattern)
+		}
+		target = p
+	}
+	if target == nil {
+		return nil, fmt.Errorf("jsonapi-codegen: no package matches %s", pattern)
+	}
+
+	names := target.GoFiles
+	if tests {
+		names = append(names, target.TestGoFiles...)
+	}
+	fset := token.NewFileSet()
+	files := make([]*ast.File, len(names))
+	for i, name := range names {
+		if files[i], err = parser.ParseFile(fset, filepath.Join(target.Dir, name), nil, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	conf := &types.Config{
+		Importer: importer.ForCompiler(fset, "gc", func(path string) (io.ReadCloser, error) {
+			export, ok := exports[path]
+			if !ok {
+				return nil, fmt.Errorf("no export data for %s", path)
+			}
+			return os.Open(export)
+		}),
+		Error: func(error) {},
+	}
+	pkg, _ := conf.Check(target.ImportPath, fset, files, nil)
+	return pkg, nil
+}
+
+func writeImports(out *bytes.Buffer, imports map[string]bool) {
+	if len(imports) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	out.WriteString("import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+}
+
+// model returns the jsonapi annotated fields of the struct type name,
+// checking them as the runtime does.
+func (g *generator) model(name string) (*model, error) {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("jsonapi-codegen: %s has no type %s", g.pkg.Path(), name)
+	}
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("jsonapi-codegen: %s is not a struct", name)
+	}
+
+	m := &model{name: name}
+	for i := 0; i < st.NumFields(); i++ {
+		tag, ok := reflect.StructTag(st.Tag(i)).Lookup(annotationJSONAPI)
+		if !ok {
+			continue
+		}
+		f, err := parseField(st.Field(i), tag)
+		if err != nil {
+			return nil, fmt.Errorf("jsonapi-codegen: %s.%s: %v", name, st.Field(i).Name(), err)
+		}
+
+		switch f.annotation {
+		case annotationPrimary:
+			if m.primary != nil {
+				return nil, fmt.Errorf("jsonapi-codegen: %s has several primary fields", name)
+			}
+			m.primary = f
+		case annotationLID:
+			m.lid = f
+		}
+		m.fields = append(m.fields, f)
+	}
+	return m, nil
+}
+
+func parseField(v *types.Var, tag string) (*field, error) {
+	args := strings.Split(tag, annotationSeperator)
+	f := &field{name: v.Name(), typ: types.Unalias(v.Type()), annotation: args[0]}
+
+	switch f.annotation {
+	case annotationClientID, annotationLID:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("bad jsonapi struct tag %q", tag)
+		}
+		if !isKind(f.typ, types.IsString) {
+			return nil, fmt.Errorf("%q field of type %s is not a string", f.annotation, f.typ)
+		}
+		return f, nil
+	case annotationPrimary, annotationAttribute, annotationRelation:
+		if len(args) < 2 || args[1] == "" {
+			return nil, fmt.Errorf("bad jsonapi struct tag %q", tag)
+		}
+	default:
+		return nil, fmt.Errorf("unknown jsonapi annotation %q", f.annotation)
+	}
+	f.member = args[1]
+
+	for _, arg := range args[2:] {
+		switch arg {
+		case annotationOmitEmpty:
+			f.omitEmpty = true
+		case annotationISO8601:
+			f.iso8601 = true
+		case annotationRFC3339:
+			f.rfc3339 = true
+		}
+	}
+
+	switch f.annotation {
+	case annotationPrimary:
+		if !isPrimaryType(f.typ) {
+			return nil, fmt.Errorf("primary field of type %s is not a string or an integer", f.typ)
+		}
+	case annotationRelation:
+		if !isRelationType(f.typ) {
+			return nil, fmt.Errorf("relation field of type %s is not a struct pointer or a slice of struct pointers", f.typ)
+		}
+	}
+	return f, nil
+}
+
+// runtime returns the qualified name of the jsonapi package member name.
+func (g *generator) runtime(name string) string {
+	if g.pkg.Path() == jsonapiPath {
+		return name
+	}
+	g.imports[jsonapiPath] = true
+	return "jsonapi." + name
+}
+
+// typeString returns the Go source of t in the generated file.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = true
+		return p.Name()
+	})
+}
+
+// convert returns the Go source converting expr, of type from, to t.
+func (g *generator) convert(expr string, t types.Type, from types.BasicKind) string {
+	if types.Identical(t, types.Typ[from]) {
+		return expr
+	}
+	return g.typeString(t) + "(" + expr + ")"
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(g.out, format, args...)
+}
+
+// marshal writes the MarshalJSONAPI method of m, which sets the members of
+// the resource object as visitModelNode does.
+func (g *generator) marshal(m *model) {
+	node := g.runtime("Node")
+
+	g.printf("// MarshalJSONAPI implements jsonapi.Marshaler.\n")
+	g.printf("func (m *%s) MarshalJSONAPI(c *%s) (*%s, error) {\n", m.name, g.runtime("MarshalContext"), node)
+	if m.primary != nil {
+		g.printf("node := &%s{Type: %q}\n", node, m.primary.member)
+	} else {
+		g.printf("node := new(%s)\n", node)
+	}
+
+	var attributes, toMany bool
+	for _, f := range m.fields {
+		attributes = attributes || f.annotation == annotationAttribute
+		toMany = toMany || f.annotation == annotationRelation && isSlice(f.typ)
+	}
+	if attributes {
+		g.printf("node.Attributes = make(map[string]interface{})\n")
+	}
+	if toMany {
+		g.printf("var related []interface{}\n")
+	}
+	g.printf("\n")
+
+	for _, f := range m.fields {
+		expr := "m." + f.name
+
+		switch f.annotation {
+		case annotationPrimary:
+			g.marshalPrimary(f)
+		case annotationClientID:
+			g.printf("node.ClientID = %s\n", g.convert(expr, types.Typ[types.String], types.String))
+		case annotationLID:
+			g.printf("node.LID = %s\n", g.convert(expr, types.Typ[types.String], types.String))
+		case annotationAttribute:
+			g.marshalAttribute(f)
+		case annotationRelation:
+			g.marshalRelation(f)
+		}
+	}
+
+	if m.lid != nil && m.primary != nil {
+		g.printf("\n// A resource identified by its local identifier has no id yet\n")
+		g.printf("if node.LID != \"\" && %s {\nnode.ID = \"\"\n}\n", g.isZeroID(m.primary))
+	}
+	g.printf("\nreturn node, nil\n}\n\n")
+}
+
+func (g *generator) marshalPrimary(f *field) {
+	expr := "m." + f.name
+	t := f.typ
+	pointer := isPointer(t)
+	if pointer {
+		t = t.Underlying().(*types.Pointer).Elem()
+		g.printf("if %s != nil {\n", expr)
+		expr = "*" + expr
+	}
+
+	switch {
+	case isKind(t, types.IsString):
+		g.printf("node.ID = %s\n", g.convert(expr, types.Typ[types.String], types.String))
+	case isKind(t, types.IsUnsigned):
+		g.imports["strconv"] = true
+		g.printf("node.ID = strconv.FormatUint(uint64(%s), 10)\n", expr)
+	default:
+		g.imports["strconv"] = true
+		g.printf("node.ID = strconv.FormatInt(int64(%s), 10)\n", expr)
+	}
+
+	if pointer {
+		g.printf("}\n")
+	}
+}
+
+// isZeroID returns the condition of the primary field f being zero.
+func (g *generator) isZeroID(f *field) string {
+	expr := "m." + f.name
+	zero := "0"
+	t := f.typ
+	if isPointer(t) {
+		t = t.Underlying().(*types.Pointer).Elem()
+	}
+	if isKind(t, types.IsString) {
+		zero = `""`
+	}
+	if isPointer(f.typ) {
+		return fmt.Sprintf("(%s == nil || *%s == %s)", expr, expr, zero)
+	}
+	return fmt.Sprintf("%s == %s", expr, zero)
+}
+
+func (g *generator) marshalAttribute(f *field) {
+	expr := "m." + f.name
+	member := strconv.Quote(f.member)
+
+	switch {
+	case isTime(f.typ):
+		g.printf("if !%s.IsZero() {\nnode.Attributes[%s] = %s\n}\n", expr, member, g.formatTime(expr, f))
+	case isTimePointer(f.typ):
+		if f.omitEmpty {
+			g.printf("if %s != nil && !%s.IsZero() {\n", expr, expr)
+			g.printf("node.Attributes[%s] = %s\n}\n", member, g.formatTime(expr, f))
+		} else {
+			g.printf("if %s != nil {\nnode.Attributes[%s] = %s\n", expr, member, g.formatTime(expr, f))
+			g.printf("} else {\nnode.Attributes[%s] = nil\n}\n", member)
+		}
+	case f.omitEmpty:
+		g.printf("if %s {\nnode.Attributes[%s] = %s\n}\n", g.isNotZero(expr, f.typ), member, expr)
+	default:
+		g.printf("node.Attributes[%s] = %s\n", member, expr)
+	}
+}
+
+// formatTime returns the attribute value of the time expr.
+func (g *generator) formatTime(expr string, f *field) string {
+	switch {
+	case f.iso8601:
+		return fmt.Sprintf("%s.UTC().Format(%q)", expr, iso8601TimeFormat)
+	case f.rfc3339:
+		g.imports["time"] = true
+		return expr + ".UTC().Format(time.RFC3339)"
+	}
+	return expr + ".Unix()"
+}
+
+// isNotZero returns the condition of expr, of type t, not being deeply equal
+// to the zero value of t.
+func (g *generator) isNotZero(expr string, t types.Type) string {
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return expr
+		case u.Info()&types.IsString != 0:
+			return expr + ` != ""`
+		case u.Info()&types.IsNumeric != 0:
+			return expr + " != 0"
+		}
+		return expr + " != nil"
+	case *types.Struct, *types.Array:
+		if types.Comparable(t) {
+			return fmt.Sprintf("%s != (%s{})", expr, g.typeString(t))
+		}
+		g.imports["reflect"] = true
+		return fmt.Sprintf("!reflect.DeepEqual(%s, %s{})", expr, g.typeString(t))
+	}
+	return expr + " != nil"
+}
+
+func (g *generator) marshalRelation(f *field) {
+	expr := "m." + f.name
+	member := strconv.Quote(f.member)
+
+	if isSlice(f.typ) {
+		if f.omitEmpty {
+			g.printf("if len(%s) > 0 {\n", expr)
+		}
+		g.printf("related = make([]interface{}, len(%s))\n", expr)
+		g.printf("for i, model := range %s {\nrelated[i] = model\n}\n", expr)
+		g.printf("if err := c.ToMany(node, %s, related); err != nil {\nreturn nil, err\n}\n", member)
+		if f.omitEmpty {
+			g.printf("}\n")
+		}
+		return
+	}
+
+	if f.omitEmpty {
+		g.printf("if %s != nil {\n", expr)
+	}
+	g.printf("if err := c.ToOne(node, %s, %s); err != nil {\nreturn nil, err\n}\n", member, expr)
+	if f.omitEmpty {
+		g.printf("}\n")
+	}
+}
+
+// unmarshal writes the UnmarshalJSONAPI method of m, which sets its fields
+// as unmarshalNode does.
+func (g *generator) unmarshal(m *model) {
+	g.printf("// UnmarshalJSONAPI implements jsonapi.Unmarshaler.\n")
+	g.printf("func (m *%s) UnmarshalJSONAPI(c *%s, node *%s) error {\n",
+		m.name, g.runtime("UnmarshalContext"), g.runtime("Node"))
+	if m.primary != nil {
+		g.printf("if err := c.CheckType(node, %q); err != nil {\nreturn err\n}\n\n", m.primary.member)
+	}
+
+	for _, f := range m.fields {
+		expr := "m." + f.name
+
+		switch f.annotation {
+		case annotationPrimary:
+			g.unmarshalPrimary(f)
+		case annotationClientID:
+			g.printf("if node.ClientID != \"\" {\n%s = %s\n}\n", expr, g.convert("node.ClientID", f.typ, types.String))
+		case annotationLID:
+			g.printf("if node.LID != \"\" {\n%s = %s\n}\n", expr, g.convert("node.LID", f.typ, types.String))
+		case annotationAttribute:
+			g.unmarshalAttribute(f)
+		case annotationRelation:
+			g.unmarshalRelation(f)
+		}
+	}
+
+	g.printf("\nreturn nil\n}\n\n")
+}
+
+// set writes the assignment of value, of the element type of the pointer
+// field f, or of its type, to the field.
+func (g *generator) set(f *field, value string) {
+	if isPointer(f.typ) {
+		g.printf("value := %s\nm.%s = &value\n", value, f.name)
+		return
+	}
+	g.printf("m.%s = %s\n", f.name, value)
+}
+
+func (g *generator) unmarshalPrimary(f *field) {
+	t := f.typ
+	if isPointer(t) {
+		t = t.Underlying().(*types.Pointer).Elem()
+	}
+
+	g.printf("if node.ID != \"\" {\n")
+	if isKind(t, types.IsString) {
+		g.set(f, g.convert("node.ID", t, types.String))
+	} else {
+		g.imports["strconv"] = true
+		g.printf("id, err := strconv.ParseFloat(node.ID, 64)\n")
+		g.printf("if err != nil {\nreturn %s\n}\n", g.runtime("ErrBadJSONAPIID"))
+		g.set(f, g.convert("id", t, types.Float64))
+	}
+	g.printf("}\n")
+}
+
+// unmarshalAttribute writes the conversion of the attribute of f. Values of
+// common types are converted directly, and the others with
+// UnmarshalContext.UnmarshalAttribute.
+func (g *generator) unmarshalAttribute(f *field) {
+	member := strconv.Quote(f.member)
+	t := f.typ
+	pointer := isPointer(t)
+	if pointer {
+		t = t.Underlying().(*types.Pointer).Elem()
+	}
+
+	var caseType string
+	var convert func()
+	switch {
+	case pointer && !isTimePointer(f.typ) && isTime(t):
+		// Named pointers to times are not converted as times
+	case isTime(t) && (f.iso8601 || f.rfc3339):
+		layout, invalid := strconv.Quote(iso8601TimeFormat), "ErrInvalidISO8601"
+		if !f.iso8601 {
+			layout, invalid = "time.RFC3339", "ErrInvalidRFC3339"
+		}
+		g.imports["time"] = true
+		caseType = "string"
+		convert = func() {
+			g.printf("t, err := time.Parse(%s, v)\n", layout)
+			g.printf("if err != nil {\nreturn %s\n}\n", g.runtime(invalid))
+			if pointer {
+				g.printf("m.%s = &t\n", f.name)
+			} else {
+				g.printf("m.%s = t\n", f.name)
+			}
+		}
+	case isTime(t):
+		g.imports["time"] = true
+		caseType = "float64"
+		convert = func() { g.set(f, "time.Unix(int64(v), 0)") }
+	case isKind(t, types.IsInteger|types.IsFloat):
+		caseType = "float64"
+		convert = func() { g.set(f, g.convert("v", t, types.Float64)) }
+	case !pointer && isKind(t, types.IsString):
+		caseType = "string"
+		convert = func() { g.set(f, g.convert("v", t, types.String)) }
+	case !pointer && isKind(t, types.IsBoolean):
+		caseType = "bool"
+		convert = func() { g.set(f, g.convert("v", t, types.Bool)) }
+	case pointer && (types.Identical(t, types.Typ[types.String]) || types.Identical(t, types.Typ[types.Bool])):
+		// Only pointers to unnamed strings and booleans are supported
+		caseType = t.String()
+		convert = func() { g.printf("m.%s = &v\n", f.name) }
+	}
+
+	if convert == nil {
+		g.printf("if err := c.UnmarshalAttribute(m, %q, node.Attributes[%s]); err != nil {\nreturn err\n}\n", f.name, member)
+		return
+	}
+
+	g.printf("switch v := node.Attributes[%s].(type) {\ncase nil:\ncase %s:\n", member, caseType)
+	convert()
+	g.printf("default:\nif err := c.UnmarshalAttribute(m, %q, v); err != nil {\nreturn err\n}\n}\n", f.name)
+}
+
+func (g *generator) unmarshalRelation(f *field) {
+	member := strconv.Quote(f.member)
+
+	if isSlice(f.typ) {
+		elem := f.typ.Underlying().(*types.Slice).Elem().Underlying().(*types.Pointer).Elem()
+		g.printf("if nodes, ok := c.ToMany(node, %s); ok {\n", member)
+		g.printf("var related %s\n", g.typeString(f.typ))
+		g.printf("for _, n := range nodes {\nmodel := new(%s)\n", g.typeString(elem))
+		g.printf("if err := c.Unmarshal(n, model); err != nil {\nreturn err\n}\n")
+		g.printf("related = append(related, model)\n}\nm.%s = related\n}\n", f.name)
+		return
+	}
+
+	elem := f.typ.Underlying().(*types.Pointer).Elem()
+	g.printf("if n := c.ToOne(node, %s); n != nil {\n", member)
+	g.printf("model := new(%s)\n", g.typeString(elem))
+	g.printf("if err := c.Unmarshal(n, model); err != nil {\nreturn err\n}\n")
+	g.printf("m.%s = model\n}\n", f.name)
+}
+
+// isPrimaryType reports whether t, or the type it points to, is a string or
+// an integer.
+func isPrimaryType(t types.Type) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return isKind(t, types.IsString|types.IsInteger)
+}
+
+func isKind(t types.Type, info types.BasicInfo) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&info != 0
+}
+
+// isRelationType reports whether t is *Struct or []*Struct.
+func isRelationType(t types.Type) bool {
+	if s, ok := t.Underlying().(*types.Slice); ok {
+		t = s.Elem()
+	}
+	p, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	_, ok = p.Elem().Underlying().(*types.Struct)
+	return ok
+}
+
+func isSlice(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Slice)
+	return ok
+}
+
+func isPointer(t types.Type) bool {
+	_, ok := t.Underlying().(*types.Pointer)
+	return ok
+}
+
+// isTimePointer reports whether t is *time.Time.
+func isTimePointer(t types.Type) bool {
+	p, ok := types.Unalias(t).(*types.Pointer)
+	return ok && isTime(p.Elem())
+}
+
+// isTime reports whether t is time.Time.
+func isTime(t types.Type) bool {
+	named, ok := types.Unalias(t).(*types.Named)
+	return ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" && named.Obj().Name() == "Time"
+}
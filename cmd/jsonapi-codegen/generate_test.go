@@ -0,0 +1,58 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	src, err := generate("./testdata/models", []string{"Article", "Person", "Tag"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "jsonapi_gen.go", src, 0); err != nil {
+		t.Fatalf("generated invalid source: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		"// Code generated by jsonapi-codegen. DO NOT EDIT.\n\npackage models\n",
+		"\"github.com/google/jsonapi\"",
+		"func (m *Article) MarshalJSONAPI(c *jsonapi.MarshalContext) (*jsonapi.Node, error) {",
+		"func (m *Article) UnmarshalJSONAPI(c *jsonapi.UnmarshalContext, node *jsonapi.Node) error {",
+		"node.ID = strconv.FormatInt(int64(m.ID), 10)",
+		"m.ID = ID(id)",
+		"if m.Published != nil && !m.Published.IsZero() {",
+		"node.Attributes[\"published\"] = m.Published.UTC().Format(time.RFC3339)",
+		"if m.Meta != nil {",
+		"if err := c.UnmarshalAttribute(m, \"Links\", node.Attributes[\"links\"]); err != nil {",
+		"if m.Author != nil {\n\t\tif err := c.ToOne(node, \"author\", m.Author); err != nil {",
+		"if err := c.ToMany(node, \"tags\", related); err != nil {",
+		"var related []*Tag",
+		"if node.LID != \"\" && m.ID == 0 {",
+		"node.ID = strconv.FormatUint(uint64(m.ID), 10)",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated source is missing %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerate_invalidModels(t *testing.T) {
+	for _, name := range []string{"Missing", "Point", "BadTag", "BadRelation"} {
+		if _, err := generate("./testdata/models", []string{name}, false); err == nil {
+			t.Errorf("want an error for %s", name)
+		}
+	}
+}
+
+func TestGenerate_untagged(t *testing.T) {
+	src, err := generate("./testdata/models", []string{"Untagged"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "node := new(jsonapi.Node)") {
+		t.Fatalf("got %s", src)
+	}
+}
@@ -0,0 +1,74 @@
+// Command jsonapi-codegen generates MarshalJSONAPI and UnmarshalJSONAPI
+// methods for models with jsonapi struct tags, so that they are converted to
+// and from resource objects without reflection (see jsonapi.Marshaler and
+// jsonapi.Unmarshaler).
+//
+// Usage:
+//
+//	jsonapi-codegen -type Blog,Post [-o jsonapi_gen.go] [-tags constraint] [package]
+//
+// The package defaults to the one in the current directory, and the methods
+// are written to stdout without -o. With -tags, the generated file starts
+// with the //go:build constraint, so that the methods are only compiled in
+// builds satisfying it. Models declared in _test.go files are
+// found when the output file is itself a _test.go file. It is typically run
+// from a go:generate directive:
+//
+//	//go:generate jsonapi-codegen -type Blog,Post -o jsonapi_gen.go
+//
+// The generated methods are promoted to structs embedding a model, so a model
+// embedding another generated model must be generated too.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonapi-codegen", flag.ContinueOnError)
+	names := flags.String("type", "", "comma-separated list of the models to generate methods for")
+	output := flags.String("o", "", "file to write the methods to, instead of stdout")
+	tags := flags.String("tags", "", "build constraint of the generated file, e.g. !purego")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *names == "" {
+		return fmt.Errorf("jsonapi-codegen: -type is required")
+	}
+
+	pattern := "."
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		pattern = flags.Arg(0)
+	default:
+		return fmt.Errorf("jsonapi-codegen: expected a single package, got %d", flags.NArg())
+	}
+
+	src, err := generate(pattern, strings.Split(*names, ","), strings.HasSuffix(*output, "_test.go"))
+	if err != nil {
+		return err
+	}
+	if *tags != "" {
+		src = append([]byte("//go:build "+*tags+"\n\n"), src...)
+	}
+
+	if *output == "" {
+		_, err := stdout.Write(src)
+		return err
+	}
+	return ioutil.WriteFile(*output, src, 0644)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// TestRun_upToDate checks that the generated methods of the models of the
+// jsonapi tests match their go:generate directive.
+func TestRun_upToDate(t *testing.T) {
+	models, err := ioutil.ReadFile("../../models_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	directive := regexp.MustCompile(`//go:generate go run ./cmd/jsonapi-codegen -type (\S+) -o (\S+) -tags (\S+)`).FindSubmatch(models)
+	if directive == nil {
+		t.Fatal("models_test.go has no jsonapi-codegen directive")
+	}
+
+	output := filepath.Join(t.TempDir(), string(directive[2]))
+	if err := run([]string{"-type", string(directive[1]), "-o", output, "-tags", string(directive[3]), "../.."}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ioutil.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile(filepath.Join("../..", string(directive[2])))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s is out of date, run go generate", directive[2])
+	}
+}
+
+func TestRun(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := run([]string{"-type", "Person", "./testdata/models"}, out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("func (m *Person) MarshalJSONAPI(")) {
+		t.Fatalf("got %s, want the methods of Person", out)
+	}
+
+	out.Reset()
+	if err := run([]string{"-type", "Person", "-tags", "!purego", "./testdata/models"}, out); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(out.Bytes(), []byte("//go:build !purego\n\n// Code generated")) {
+		t.Fatalf("got %s, want the build constraint first", out)
+	}
+
+	if err := run([]string{"./testdata/models"}, out); err == nil {
+		t.Fatal("want an error without -type")
+	}
+}
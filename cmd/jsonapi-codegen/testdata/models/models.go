@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/jsonapi"
+)
+
+type ID int64
+
+type Article struct {
+	ID        ID                     `jsonapi:"primary,articles"`
+	LID       string                 `jsonapi:"lid"`
+	Title     string                 `jsonapi:"attr,title"`
+	Published *time.Time             `jsonapi:"attr,published,rfc3339,omitempty"`
+	Meta      map[string]interface{} `jsonapi:"attr,meta,omitempty"`
+	Links     *jsonapi.Links         `jsonapi:"attr,links,omitempty"`
+	Author    *Person                `jsonapi:"relation,author,omitempty"`
+	Tags      []*Tag                 `jsonapi:"relation,tags"`
+}
+
+type Person struct {
+	ID string `jsonapi:"primary,people"`
+}
+
+type Tag struct {
+	ID uint8 `jsonapi:"primary,tags"`
+}
+
+type Untagged struct {
+	Name string
+}
+
+type BadTag struct {
+	ID string `jsonapi:"primary"`
+}
+
+type BadRelation struct {
+	ID     string `jsonapi:"primary,bad"`
+	Person Person `jsonapi:"relation,person"`
+}
+
+type Point int
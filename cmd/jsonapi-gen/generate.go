@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/jsonapi"
+	"github.com/google/jsonapi/openapi"
+)
+
+// errNoResources is returned for definitions without any resource schema.
+var errNoResources = errors.New("jsonapi-gen: no resource object schemas found")
+
+// initialisms are written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "ascii": true, "cpu": true, "css": true, "dns": true, "html": true,
+	"http": true, "https": true, "id": true, "ip": true, "json": true, "lid": true,
+	"sql": true, "uri": true, "url": true, "uuid": true, "xml": true,
+}
+
+// resource is a resource object schema to generate a model for.
+type resource struct {
+	typeName string
+	goName   string
+	schema   *jsonapi.Schema
+}
+
+// generator generates the Go models of the resource object schemas of a JSON
+// Schema or OpenAPI document.
+type generator struct {
+	pkg       string
+	refs      map[string]*jsonapi.Schema
+	resources []*resource
+	byType    map[string]*resource
+	imports   map[string]bool
+	nested    []string
+	names     map[string]bool
+}
+
+// newGenerator finds the resource object schemas of in, a JSON Schema as
+// generated by jsonapi.ResourceSchema or jsonapi.DocumentSchema, or an
+// OpenAPI document as generated by the openapi package.
+func newGenerator(pkg string, in []byte) (*generator, error) {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(in, &probe); err != nil {
+		return nil, err
+	}
+
+	g := &generator{
+		pkg:     pkg,
+		refs:    map[string]*jsonapi.Schema{},
+		byType:  map[string]*resource{},
+		imports: map[string]bool{},
+		names:   map[string]bool{},
+	}
+
+	if _, isOpenAPI := probe["openapi"]; isOpenAPI {
+		doc := new(openapi.Document)
+		if err := json.Unmarshal(in, doc); err != nil {
+			return nil, err
+		}
+		if doc.Components != nil {
+			g.refs = doc.Components.Schemas
+		}
+	} else {
+		root := new(jsonapi.Schema)
+		if err := json.Unmarshal(in, root); err != nil {
+			return nil, err
+		}
+		for name, s := range root.Defs {
+			g.refs[name] = s
+		}
+		g.refs[""] = root
+	}
+
+	// Sorted so that a resource schema wins over its request variant
+	names := make([]string, 0, len(g.refs))
+	for name := range g.refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := g.refs[name]
+		typeName, ok := resourceType(s)
+		if !ok || g.byType[typeName] != nil {
+			continue
+		}
+		r := &resource{typeName: typeName, goName: g.uniqueName(goName(singular(typeName))), schema: s}
+		g.resources = append(g.resources, r)
+		g.byType[typeName] = r
+	}
+
+	if len(g.resources) == 0 {
+		return nil, errNoResources
+	}
+	return g, nil
+}
+
+// resourceType returns the constant type of a resource object schema.
+func resourceType(s *jsonapi.Schema) (string, bool) {
+	if s == nil || s.Properties["type"] == nil {
+		return "", false
+	}
+	name, ok := s.Properties["type"].Const.(string)
+	return name, ok && name != ""
+}
+
+// models returns the source of the models of the resources.
+func (g *generator) models() ([]byte, error) {
+	body := &bytes.Buffer{}
+	// Related types without a schema of their own are appended while the
+	// models are written
+	for i := 0; i < len(g.resources); i++ {
+		g.model(body, g.resources[i])
+	}
+	for _, n := range g.nested {
+		body.WriteString(n)
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by jsonapi-gen. DO NOT EDIT.\n\npackage %s\n\n", g.pkg)
+	writeImports(out, g.imports)
+	out.Write(body.Bytes())
+
+	return format.Source(out.Bytes())
+}
+
+// stubs returns the source of the Linkable and Metable methods of the
+// models, to be filled in by hand. It must be called after models.
+func (g *generator) stubs() ([]byte, error) {
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "package %s\n\n", g.pkg)
+	writeImports(out, map[string]bool{"github.com/google/jsonapi": true})
+
+	for _, r := range g.resources {
+		recv := strings.ToLower(string([]rune(r.goName)[:1]))
+		fmt.Fprintf(out, "// JSONAPILinks implements jsonapi.Linkable.\n")
+		fmt.Fprintf(out, "func (%s *%s) JSONAPILinks() *jsonapi.Links {\n\treturn nil\n}\n\n", recv, r.goName)
+		fmt.Fprintf(out, "// JSONAPIMeta implements jsonapi.Metable.\n")
+		fmt.Fprintf(out, "func (%s *%s) JSONAPIMeta() *jsonapi.Meta {\n\treturn nil\n}\n\n", recv, r.goName)
+
+		if relationships := r.schema.Properties["relationships"]; relationships != nil && len(relationships.Properties) > 0 {
+			fmt.Fprintf(out, "// JSONAPIRelationshipLinks implements jsonapi.RelationshipLinkable.\n")
+			fmt.Fprintf(out, "func (%s *%s) JSONAPIRelationshipLinks(relation string) *jsonapi.Links {\n\treturn nil\n}\n\n", recv, r.goName)
+			fmt.Fprintf(out, "// JSONAPIRelationshipMeta implements jsonapi.RelationshipMetable.\n")
+			fmt.Fprintf(out, "func (%s *%s) JSONAPIRelationshipMeta(relation string) *jsonapi.Meta {\n\treturn nil\n}\n\n", recv, r.goName)
+		}
+	}
+
+	return format.Source(out.Bytes())
+}
+
+func writeImports(out *bytes.Buffer, imports map[string]bool) {
+	if len(imports) == 0 {
+		return
+	}
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	out.WriteString("import (\n")
+	for _, path := range paths {
+		fmt.Fprintf(out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+}
+
+// model writes the struct of a resource: its identifiers, then its
+// attributes and relationships in name order.
+func (g *generator) model(out *bytes.Buffer, r *resource) {
+	fields := &fieldList{names: map[string]bool{}}
+	fields.add("ID", "string", fmt.Sprintf("primary,%s", r.typeName))
+	if r.schema.Properties["lid"] != nil {
+		fields.add("LID", "string", "lid")
+	}
+	if r.schema.Properties["client-id"] != nil {
+		fields.add("ClientID", "string", "client-id")
+	}
+
+	if attributes := r.schema.Properties["attributes"]; attributes != nil {
+		for _, name := range sortedNames(attributes.Properties) {
+			s := g.resolve(attributes.Properties[name])
+			goType, date := g.goType(s, r.goName+goName(name))
+			tag := "attr," + name
+			if date {
+				tag += ",rfc3339"
+			}
+			if !contains(attributes.Required, name) {
+				tag += ",omitempty"
+			}
+			fields.add(goName(name), goType, tag)
+		}
+	}
+
+	if relationships := r.schema.Properties["relationships"]; relationships != nil {
+		for _, name := range sortedNames(relationships.Properties) {
+			goType := g.relationType(g.resolve(relationships.Properties[name]))
+			tag := "relation," + name
+			if !contains(relationships.Required, name) {
+				tag += ",omitempty"
+			}
+			fields.add(goName(name), goType, tag)
+		}
+	}
+
+	fmt.Fprintf(out, "// %s is a resource of type %q.\ntype %s struct {\n", r.goName, r.typeName, r.goName)
+	for _, f := range fields.fields {
+		fmt.Fprintf(out, "\t%s %s `jsonapi:\"%s\"`\n", f.name, f.goType, f.tag)
+	}
+	out.WriteString("}\n\n")
+}
+
+// relationType returns the Go type of a relation from the linkage schema of
+// the relationship object s.
+func (g *generator) relationType(s *jsonapi.Schema) string {
+	data := g.resolve(s.Properties["data"])
+	if data == nil {
+		return "interface{}"
+	}
+
+	many := false
+	identifier := data
+	switch {
+	case data.Items != nil:
+		many = true
+		identifier = g.resolve(data.Items)
+	case len(data.OneOf) > 0:
+		for _, option := range data.OneOf {
+			if option = g.resolve(option); option.Type != "null" {
+				identifier = option
+			}
+		}
+	}
+
+	related := g.relatedType(identifier)
+	if many {
+		return "[]*" + related
+	}
+	return "*" + related
+}
+
+// relatedType returns the Go name of the model of the resources a resource
+// identifier schema points to, adding a model with only an id for types
+// without a schema of their own.
+func (g *generator) relatedType(identifier *jsonapi.Schema) string {
+	typeName, ok := resourceType(identifier)
+	if !ok {
+		return "struct{}"
+	}
+	if r, exists := g.byType[typeName]; exists {
+		return r.goName
+	}
+
+	r := &resource{
+		typeName: typeName,
+		goName:   g.uniqueName(goName(singular(typeName))),
+		schema:   &jsonapi.Schema{Properties: map[string]*jsonapi.Schema{}},
+	}
+	g.byType[typeName] = r
+	g.resources = append(g.resources, r)
+	return r.goName
+}
+
+// goType returns the Go type of the values described by s, and whether they
+// are date-time strings. Objects with properties become structs named after
+// hint.
+func (g *generator) goType(s *jsonapi.Schema, hint string) (string, bool) {
+	if s == nil {
+		return "interface{}", false
+	}
+
+	typ, nullable := schemaType(s)
+	pointer := ""
+	if nullable {
+		pointer = "*"
+	}
+
+	switch typ {
+	case "string":
+		switch {
+		case s.Format == "date-time":
+			g.imports["time"] = true
+			return pointer + "time.Time", true
+		case s.ContentEncoding == "base64":
+			return "[]byte", false
+		}
+		return pointer + "string", false
+	case "integer":
+		if s.Minimum != nil && *s.Minimum >= 0 {
+			return pointer + "uint", false
+		}
+		return pointer + "int", false
+	case "number":
+		return pointer + "float64", false
+	case "boolean":
+		return pointer + "bool", false
+	case "array":
+		elem, _ := g.goType(g.resolve(s.Items), singular(hint))
+		return "[]" + elem, false
+	case "object":
+		if len(s.Properties) > 0 {
+			return pointer + g.nestedStruct(s, hint), false
+		}
+		if values, ok := s.AdditionalProperties.(map[string]interface{}); ok {
+			elem, _ := g.goType(g.resolve(toSchema(values)), singular(hint))
+			return "map[string]" + elem, false
+		}
+		return "map[string]interface{}", false
+	}
+
+	return "interface{}", false
+}
+
+// nestedStruct adds the struct of an object attribute, encoded with
+// encoding/json, and returns its name.
+func (g *generator) nestedStruct(s *jsonapi.Schema, hint string) string {
+	name := g.uniqueName(hint)
+	fields := &fieldList{names: map[string]bool{}}
+	for _, property := range sortedNames(s.Properties) {
+		goType, _ := g.goType(g.resolve(s.Properties[property]), name+goName(property))
+		tag := property
+		if !contains(s.Required, property) {
+			tag += ",omitempty"
+		}
+		fields.add(goName(property), goType, tag)
+	}
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// %s is an attribute value.\ntype %s struct {\n", name, name)
+	for _, f := range fields.fields {
+		fmt.Fprintf(out, "\t%s %s `json:\"%s\"`\n", f.name, f.goType, f.tag)
+	}
+	out.WriteString("}\n\n")
+	g.nested = append(g.nested, out.String())
+
+	return name
+}
+
+// resolve follows the $ref of s to the schemas of the definitions.
+func (g *generator) resolve(s *jsonapi.Schema) *jsonapi.Schema {
+	for i := 0; s != nil && s.Ref != "" && i < 32; i++ {
+		s = g.refs[s.Ref[strings.LastIndex(s.Ref, "/")+1:]]
+	}
+	return s
+}
+
+func (g *generator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+// schemaType returns the JSON type of s, and whether it may be null.
+func schemaType(s *jsonapi.Schema) (string, bool) {
+	switch t := s.Type.(type) {
+	case string:
+		return t, false
+	case []interface{}:
+		typ, nullable := "", false
+		for _, option := range t {
+			if option == "null" {
+				nullable = true
+			} else if name, ok := option.(string); ok && typ == "" {
+				typ = name
+			}
+		}
+		return typ, nullable
+	case []string:
+		typ, nullable := "", false
+		for _, option := range t {
+			if option == "null" {
+				nullable = true
+			} else if typ == "" {
+				typ = option
+			}
+		}
+		return typ, nullable
+	}
+	if s.Properties != nil {
+		return "object", false
+	}
+	return "", false
+}
+
+// toSchema converts a schema decoded as a map, e.g. additionalProperties.
+func toSchema(v map[string]interface{}) *jsonapi.Schema {
+	s := new(jsonapi.Schema)
+	raw, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(raw, s)
+	}
+	if err != nil {
+		return nil
+	}
+	return s
+}
+
+// goName converts a member name, e.g. "created_at", to an exported Go name,
+// e.g. "CreatedAt".
+func goName(member string) string {
+	parts := strings.FieldsFunc(member, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	name := ""
+	for _, part := range parts {
+		if initialisms[strings.ToLower(part)] {
+			name += strings.ToUpper(part)
+			continue
+		}
+		runes := []rune(part)
+		name += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// singular returns the singular of an English plural, for the model names
+// of resource types such as "posts" or "categories".
+func singular(plural string) string {
+	lower := strings.ToLower(plural)
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(plural) > 3:
+		return plural[:len(plural)-3] + "y"
+	case strings.HasSuffix(lower, "sses"), strings.HasSuffix(lower, "xes"),
+		strings.HasSuffix(lower, "ches"), strings.HasSuffix(lower, "shes"):
+		return plural[:len(plural)-2]
+	case strings.HasSuffix(lower, "ss"), strings.HasSuffix(lower, "us"):
+		return plural
+	case strings.HasSuffix(lower, "s") && len(plural) > 1:
+		return plural[:len(plural)-1]
+	case lower == "people":
+		return plural[:1] + "erson"
+	}
+	return plural
+}
+
+type field struct {
+	name   string
+	goType string
+	tag    string
+}
+
+// fieldList collects the fields of a struct with unique names.
+type fieldList struct {
+	fields []*field
+	names  map[string]bool
+}
+
+func (l *fieldList) add(name, goType, tag string) {
+	unique := name
+	for i := 2; l.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	l.names[unique] = true
+	l.fields = append(l.fields, &field{name: unique, goType: goType, tag: tag})
+}
+
+func sortedNames(schemas map[string]*jsonapi.Schema) []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/jsonapi"
+	"github.com/google/jsonapi/openapi"
+)
+
+type Article struct {
+	ID          string     `jsonapi:"primary,articles"`
+	LID         string     `jsonapi:"lid"`
+	Title       string     `jsonapi:"attr,title"`
+	Views       *uint      `jsonapi:"attr,views,omitempty"`
+	Tags        []string   `jsonapi:"attr,tags"`
+	PublishedAt time.Time  `jsonapi:"attr,published_at,iso8601"`
+	Source      *Source    `jsonapi:"attr,source"`
+	Author      *Person    `jsonapi:"relation,author"`
+	Comments    []*Comment `jsonapi:"relation,comments,omitempty"`
+}
+
+type Source struct {
+	URL  string `json:"url"`
+	Page int    `json:"page,omitempty"`
+}
+
+type Person struct {
+	ID   int    `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type Comment struct {
+	ID int `jsonapi:"primary,comments"`
+}
+
+const wantModels = `// Code generated by jsonapi-gen. DO NOT EDIT.
+
+package models
+
+import (
+	"time"
+)
+
+// Article is a resource of type "articles".
+type Article struct {
+	ID          string         ` + "`" + `jsonapi:"primary,articles"` + "`" + `
+	LID         string         ` + "`" + `jsonapi:"lid"` + "`" + `
+	PublishedAt time.Time      ` + "`" + `jsonapi:"attr,published_at,rfc3339,omitempty"` + "`" + `
+	Source      *ArticleSource ` + "`" + `jsonapi:"attr,source"` + "`" + `
+	Tags        []string       ` + "`" + `jsonapi:"attr,tags"` + "`" + `
+	Title       string         ` + "`" + `jsonapi:"attr,title"` + "`" + `
+	Views       *uint          ` + "`" + `jsonapi:"attr,views,omitempty"` + "`" + `
+	Author      *Person        ` + "`" + `jsonapi:"relation,author"` + "`" + `
+	Comments    []*Comment     ` + "`" + `jsonapi:"relation,comments,omitempty"` + "`" + `
+}
+
+// Person is a resource of type "people".
+type Person struct {
+	ID   string ` + "`" + `jsonapi:"primary,people"` + "`" + `
+	Name string ` + "`" + `jsonapi:"attr,name"` + "`" + `
+}
+
+// Comment is a resource of type "comments".
+type Comment struct {
+	ID string ` + "`" + `jsonapi:"primary,comments"` + "`" + `
+}
+
+// ArticleSource is an attribute value.
+type ArticleSource struct {
+	Page int    ` + "`" + `json:"page,omitempty"` + "`" + `
+	URL  string ` + "`" + `json:"url"` + "`" + `
+}
+`
+
+func generate(t *testing.T, definitions interface{}) *generator {
+	t.Helper()
+	in, err := json.Marshal(definitions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := newGenerator("models", in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestGenerator_openAPI(t *testing.T) {
+	doc := openapi.New("Articles", "1.0.0")
+	for _, model := range []interface{}{new(Article), new(Person)} {
+		if err := doc.Register(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	models, err := generate(t, doc).models()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(models) != wantModels {
+		t.Fatalf("got\n%s\nwant\n%s", models, wantModels)
+	}
+}
+
+func TestGenerator_jsonSchema(t *testing.T) {
+	schema, err := jsonapi.DocumentSchema(new(Article))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g := generate(t, schema)
+	models, err := g.models()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Related types without a schema get a model with only an id
+	want := strings.Replace(wantModels, `	ID   string `+"`"+`jsonapi:"primary,people"`+"`"+`
+	Name string `+"`"+`jsonapi:"attr,name"`+"`"+`
+`, `	ID string `+"`"+`jsonapi:"primary,people"`+"`"+`
+`, 1)
+	if want == wantModels {
+		t.Fatal("want the Person model without attributes")
+	}
+	if string(models) != want {
+		t.Fatalf("got\n%s\nwant\n%s", models, want)
+	}
+
+	stubs, err := g.stubs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "stubs.go", stubs, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Article has relationships, Person and Comment don't
+	if got := len(f.Decls) - 1; got != 8 {
+		t.Fatalf("got %d stub declarations, want 8 in\n%s", got, stubs)
+	}
+}
+
+func TestGenerator_noResources(t *testing.T) {
+	if _, err := newGenerator("models", []byte(`{"type": "object"}`)); err != errNoResources {
+		t.Fatalf("got %v, want %v", err, errNoResources)
+	}
+	if _, err := newGenerator("models", []byte(`[]`)); err == nil {
+		t.Fatal("want an error for invalid definitions")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for member, want := range map[string]string{
+		"title":         "Title",
+		"created_at":    "CreatedAt",
+		"first-name":    "FirstName",
+		"comment count": "CommentCount",
+		"author_id":     "AuthorID",
+		"homepage-url":  "HomepageURL",
+		"naïve":         "Naïve",
+		"2fa":           "X2fa",
+	} {
+		if got := goName(member); got != want {
+			t.Errorf("goName(%q) = %q, want %q", member, got, want)
+		}
+	}
+}
+
+func TestSingular(t *testing.T) {
+	for plural, want := range map[string]string{
+		"posts":      "post",
+		"categories": "category",
+		"addresses":  "address",
+		"boxes":      "box",
+		"status":     "status",
+		"people":     "person",
+		"news":       "new",
+		"data":       "data",
+	} {
+		if got := singular(plural); got != want {
+			t.Errorf("singular(%q) = %q, want %q", plural, got, want)
+		}
+	}
+}
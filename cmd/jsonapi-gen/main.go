@@ -0,0 +1,82 @@
+// Command jsonapi-gen generates Go models with jsonapi struct tags from the
+// resource object schemas of a JSON Schema, as generated by
+// jsonapi.ResourceSchema and jsonapi.DocumentSchema, or of an OpenAPI
+// document, as generated by the openapi package.
+//
+// Usage:
+//
+//	jsonapi-gen [-package name] [-o models.go] [-stubs links.go] [schema.json]
+//
+// The schema is read from stdin when no file is given, and the models are
+// written to stdout without -o. With -stubs, JSONAPILinks and JSONAPIMeta
+// methods returning nil are written to a separate file, to be implemented by
+// hand; an existing file is never overwritten.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonapi-gen", flag.ContinueOnError)
+	pkg := flags.String("package", "models", "package name of the generated code")
+	output := flags.String("o", "", "file to write the models to, instead of stdout")
+	stubs := flags.String("stubs", "", "file to write Linkable and Metable stubs to")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	in, err := readInput(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+
+	g, err := newGenerator(*pkg, in)
+	if err != nil {
+		return err
+	}
+	models, err := g.models()
+	if err != nil {
+		return err
+	}
+
+	if *stubs != "" {
+		if _, err := os.Stat(*stubs); err == nil {
+			return fmt.Errorf("jsonapi-gen: %s already exists", *stubs)
+		}
+		methods, err := g.stubs()
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*stubs, methods, 0644); err != nil {
+			return err
+		}
+	}
+
+	if *output == "" {
+		_, err := stdout.Write(models)
+		return err
+	}
+	return ioutil.WriteFile(*output, models, 0644)
+}
+
+func readInput(files []string, stdin io.Reader) ([]byte, error) {
+	switch len(files) {
+	case 0:
+		return ioutil.ReadAll(stdin)
+	case 1:
+		return ioutil.ReadFile(files[0])
+	}
+	return nil, fmt.Errorf("jsonapi-gen: expected a single schema file, got %d", len(files))
+}
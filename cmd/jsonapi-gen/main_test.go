@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+func TestRun(t *testing.T) {
+	schema, err := jsonapi.ResourceSchema(new(Person))
+	if err != nil {
+		t.Fatal(err)
+	}
+	in, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	stubs := filepath.Join(dir, "links.go")
+	out := bytes.NewBuffer(nil)
+	if err := run([]string{"-package", "api", "-stubs", stubs}, bytes.NewReader(in), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "package api\n") || !strings.Contains(out.String(), "type Person struct") {
+		t.Fatalf("got %s, want the Person model in package api", out)
+	}
+	methods, err := ioutil.ReadFile(stubs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(methods), "func (p *Person) JSONAPILinks() *jsonapi.Links") {
+		t.Fatalf("got stubs %s", methods)
+	}
+
+	// Stubs implemented by hand are never overwritten
+	if err := run([]string{"-stubs", stubs}, bytes.NewReader(in), out); err == nil {
+		t.Fatal("want an error for an existing stubs file")
+	}
+
+	models := filepath.Join(dir, "models.go")
+	input := filepath.Join(dir, "schema.json")
+	if err := ioutil.WriteFile(input, in, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"-o", models, input}, nil, out); err != nil {
+		t.Fatal(err)
+	}
+	if written, err := ioutil.ReadFile(models); err != nil || !bytes.Contains(written, []byte("type Person struct")) {
+		t.Fatalf("got %s and %v, want the models written to -o", written, err)
+	}
+}
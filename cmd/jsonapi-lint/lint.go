@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/google/jsonapi"
+)
+
+// finding is a violation of the JSON API specification in a document.
+type finding struct {
+	File    string `json:"file"`
+	Pointer string `json:"pointer"`
+	Title   string `json:"title"`
+	Detail  string `json:"detail"`
+}
+
+// lint returns the violations of the document in: its structure and member
+// names (jsonapi.ValidateDocument), then, if it has primary data, its full
+// linkage and duplicate resources (jsonapi.ValidateLinkage). A linkage
+// violation is left out if the structure of the same member is invalid, as
+// for a null resource.
+func lint(file string, in []byte) []*finding {
+	errs := jsonapi.ValidateDocument(bytes.NewReader(in))
+	if doc, err := jsonapi.ReadDocument(bytes.NewReader(in)); err == nil {
+		invalid := map[string]bool{}
+		for _, obj := range errs {
+			if obj.Source != nil {
+				invalid[obj.Source.Pointer] = true
+			}
+		}
+		for _, obj := range jsonapi.ValidateLinkage(doc.Payload()) {
+			if obj.Source == nil || !invalid[obj.Source.Pointer] {
+				errs = append(errs, obj)
+			}
+		}
+	}
+
+	findings := make([]*finding, len(errs))
+	for i, obj := range errs {
+		findings[i] = &finding{File: file, Title: obj.Title, Detail: obj.Detail}
+		if obj.Source != nil {
+			findings[i].Pointer = obj.Source.Pointer
+		}
+	}
+	return findings
+}
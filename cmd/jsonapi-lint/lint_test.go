@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func findingPointers(findings []*finding) []string {
+	pointers := []string{}
+	for _, f := range findings {
+		pointers = append(pointers, f.Pointer)
+	}
+	return pointers
+}
+
+func TestLint(t *testing.T) {
+	for _, test := range []struct {
+		document string
+		want     []string
+	}{
+		{`{"data":{"type":"posts","id":"1"}}`, []string{}},
+		{`{"data":[{"type":"posts","id":"1"}],"included":[]}`, []string{}},
+		{`{"data":null}`, []string{}},
+		{`{"errors":[{"status":"404"}]}`, []string{}},
+		{`{"data":`, []string{""}},
+		{`{"data":{"type":"posts","id":"1","attributes":{"links":1,"-title":2}}}`, []string{"/data/attributes/-title", "/data/attributes/links"}},
+		{`{"data":{"type":"posts","id":"1","relationships":{"type":{"data":null}}}}`, []string{"/data/relationships/type"}},
+		{`{"data":[{"type":"posts","id":"1"},{"type":"posts","id":"1"}]}`, []string{"/data/1"}},
+		{`{"data":{"type":"posts","id":"1","relationships":{"author":{"data":{"type":"people","id":"2"}}}},` +
+			`"included":[{"type":"people","id":"2"},{"type":"people","id":"3"},{"type":"posts","id":"1"}]}`,
+			[]string{"/included/2", "/included/1"}},
+		{`{"data":[null]}`, []string{"/data/0"}},
+		{`{"data":[{"type":"posts","id":"1"},null,{"type":"posts","id":"1"}]}`, []string{"/data/1", "/data/2"}},
+		{`{"data":{"type":"posts","id":"1"},"included":[null]}`, []string{"/included/0"}},
+	} {
+		if got := findingPointers(lint("doc.json", []byte(test.document))); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got violations at %q, want %q", test.document, got, test.want)
+		}
+	}
+}
+
+func TestLint_findings(t *testing.T) {
+	findings := lint("doc.json", []byte(`{"data":{"type":"posts","id":"1","attributes":{"id":"2"}}}`))
+	want := []*finding{{
+		File:    "doc.json",
+		Pointer: "/data/attributes/id",
+		Title:   "Invalid Document",
+		Detail:  `"id" can't be used as an attribute name`,
+	}}
+	if !reflect.DeepEqual(findings, want) {
+		t.Fatalf("got %+v, want %+v", findings[0], want[0])
+	}
+}
@@ -0,0 +1,85 @@
+// Command jsonapi-lint checks JSON API documents, e.g. recorded API
+// responses, against the specification: their structure, member names, full
+// linkage and duplicate resources.
+//
+// Usage:
+//
+//	jsonapi-lint [-json] [document.json ...]
+//
+// The document is read from stdin when no file is given. Each violation is
+// reported on a line of its own, as "file: pointer: detail", where the
+// pointer is omitted for violations of the whole document, or with -json
+// as a JSON array of objects with file, pointer, title and detail members.
+// The exit status is 1 if a document is invalid, and 2 if it can't be read.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// errInvalid is returned by run when a document has violations.
+var errInvalid = errors.New("jsonapi-lint: invalid document")
+
+func main() {
+	err := run(os.Args[1:], os.Stdin, os.Stdout)
+	switch {
+	case err == errInvalid:
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonapi-lint", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "report the violations as JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	findings := []*finding{}
+	if flags.NArg() == 0 {
+		in, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, lint("<stdin>", in)...)
+	}
+	for _, file := range flags.Args() {
+		in, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, lint(file, in)...)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			return err
+		}
+	} else {
+		for _, f := range findings {
+			location := f.File
+			if f.Pointer != "" {
+				location += ": " + f.Pointer
+			}
+			if _, err := fmt.Fprintf(stdout, "%s: %s\n", location, f.Detail); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(findings) > 0 {
+		return errInvalid
+	}
+	return nil
+}
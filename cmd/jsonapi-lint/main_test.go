@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const invalidDocument = `{"data":{"type":"posts","id":"1","attributes":{"type":"post"}}}`
+
+func TestRun(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := run(nil, strings.NewReader(invalidDocument), out); err != errInvalid {
+		t.Fatalf("got error %v, want errInvalid", err)
+	}
+	if want := "<stdin>: /data/attributes/type: \"type\" can't be used as an attribute name\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+
+	out.Reset()
+	if err := run(nil, strings.NewReader("[]"), out); err != errInvalid {
+		t.Fatalf("got error %v, want errInvalid", err)
+	}
+	if want := "<stdin>: A document must be an object\n"; out.String() != want {
+		t.Fatalf("got %q, want %q", out, want)
+	}
+}
+
+func TestRun_files(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(valid, []byte(`{"data":null}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(invalid, []byte(invalidDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := run([]string{valid}, nil, out); err != nil || out.Len() != 0 {
+		t.Fatalf("got %q and error %v for a valid document", out, err)
+	}
+
+	if err := run([]string{"-json", valid, invalid}, nil, out); err != errInvalid {
+		t.Fatalf("got error %v, want errInvalid", err)
+	}
+	var findings []*finding
+	if err := json.Unmarshal(out.Bytes(), &findings); err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].File != invalid || findings[0].Pointer != "/data/attributes/type" {
+		t.Fatalf("got %s", out)
+	}
+
+	if err := run([]string{filepath.Join(dir, "missing.json")}, nil, out); err == nil || err == errInvalid {
+		t.Fatalf("got error %v, want a read error", err)
+	}
+}
+
+func TestRun_jsonWithoutViolations(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := run([]string{"-json"}, strings.NewReader(`{"data":null}`), out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Fatalf("got %q, want an empty array", out)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/google/jsonapi"
+)
+
+// flatDocument is the plain JSON view of a document.
+type flatDocument struct {
+	Data     interface{}              `json:"data"`
+	Included []map[string]interface{} `json:"included,omitempty"`
+}
+
+func flattenCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	in, err := readInput(args, stdin)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeDocument(in)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, flatten(doc))
+}
+
+func flatten(doc *jsonapi.Document) *flatDocument {
+	flat := &flatDocument{}
+
+	data := make([]map[string]interface{}, len(doc.Data()))
+	for i, n := range doc.Data() {
+		data[i] = flattenNode(n)
+	}
+	switch {
+	case isMany(doc):
+		flat.Data = data
+	case len(data) > 0:
+		flat.Data = data[0]
+	}
+
+	for _, n := range doc.Included() {
+		flat.Included = append(flat.Included, flattenNode(n))
+	}
+	return flat
+}
+
+// flattenNode returns the type, id, lid, attributes and relationship linkage
+// of n as the members of an object, or nil if n is null.
+func flattenNode(n *jsonapi.Node) map[string]interface{} {
+	if n == nil {
+		return nil
+	}
+	flat := map[string]interface{}{"type": n.Type}
+	if n.ID != "" {
+		flat["id"] = n.ID
+	}
+	if n.LID != "" {
+		flat["lid"] = n.LID
+	}
+	for name, value := range n.Attributes {
+		flat[name] = value
+	}
+
+	for name, value := range n.Relationships {
+		relationship, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		data, hasData := relationship["data"]
+		if !hasData {
+			continue
+		}
+		switch data := data.(type) {
+		case map[string]interface{}:
+			flat[name] = flattenIdentifier(data)
+		case []interface{}:
+			identifiers := make([]interface{}, len(data))
+			for i, d := range data {
+				identifiers[i] = d
+				if obj, ok := d.(map[string]interface{}); ok {
+					identifiers[i] = flattenIdentifier(obj)
+				}
+			}
+			flat[name] = identifiers
+		default:
+			flat[name] = data
+		}
+	}
+	return flat
+}
+
+// flattenIdentifier returns the type, id and lid of a resource identifier
+// object.
+func flattenIdentifier(obj map[string]interface{}) map[string]interface{} {
+	flat := map[string]interface{}{}
+	for _, member := range []string{"type", "id", "lid"} {
+		if v, ok := obj[member]; ok {
+			flat[member] = v
+		}
+	}
+	return flat
+}
+
+func unflattenCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	in, err := readInput(args, stdin)
+	if err != nil {
+		return err
+	}
+	payload, err := unflatten(in)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, payload)
+}
+
+// unflatten returns the document of the plain JSON view in.
+func unflatten(in []byte) (jsonapi.Payloader, error) {
+	var flat struct {
+		Data     json.RawMessage          `json:"data"`
+		Included []map[string]interface{} `json:"included"`
+	}
+	if err := json.Unmarshal(in, &flat); err != nil {
+		return nil, fmt.Errorf("jsonapi: invalid flat document: %v", err)
+	}
+
+	included := []*jsonapi.Node{}
+	for _, obj := range flat.Included {
+		n, err := unflattenNode(obj)
+		if err != nil {
+			return nil, err
+		}
+		included = append(included, n)
+	}
+	if len(included) == 0 {
+		included = nil
+	}
+
+	var data interface{}
+	if err := json.Unmarshal(flat.Data, &data); err != nil {
+		return nil, fmt.Errorf("jsonapi: invalid flat document: %v", err)
+	}
+	switch data := data.(type) {
+	case nil:
+		return &jsonapi.OnePayload{Included: included}, nil
+	case map[string]interface{}:
+		n, err := unflattenNode(data)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonapi.OnePayload{Data: n, Included: included}, nil
+	case []interface{}:
+		nodes := []*jsonapi.Node{}
+		for _, d := range data {
+			obj, ok := d.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("jsonapi: flat resource %v is not an object", d)
+			}
+			n, err := unflattenNode(obj)
+			if err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, n)
+		}
+		return &jsonapi.ManyPayload{Data: nodes, Included: included}, nil
+	}
+	return nil, fmt.Errorf("jsonapi: flat data %v is not an object or an array", data)
+}
+
+// unflattenNode returns the resource object of the flat resource obj.
+func unflattenNode(obj map[string]interface{}) (*jsonapi.Node, error) {
+	n := new(jsonapi.Node)
+	var ok bool
+	if n.Type, ok = obj["type"].(string); !ok {
+		return nil, fmt.Errorf("jsonapi: flat resource %v has no type", obj)
+	}
+	n.ID, _ = obj["id"].(string)
+	n.LID, _ = obj["lid"].(string)
+
+	for name, value := range obj {
+		switch name {
+		case "type", "id", "lid":
+			continue
+		}
+		if isLinkage(value) {
+			if n.Relationships == nil {
+				n.Relationships = map[string]interface{}{}
+			}
+			n.Relationships[name] = map[string]interface{}{"data": value}
+			continue
+		}
+		if n.Attributes == nil {
+			n.Attributes = map[string]interface{}{}
+		}
+		n.Attributes[name] = value
+	}
+	return n, nil
+}
+
+// isLinkage reports whether value is a resource identifier, an object of a
+// type and an id or lid, or a non-empty array of them.
+func isLinkage(value interface{}) bool {
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			if !isIdentifier(v) {
+				return false
+			}
+		}
+		return len(values) > 0
+	}
+	return isIdentifier(value)
+}
+
+func isIdentifier(value interface{}) bool {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for member, v := range obj {
+		if _, isString := v.(string); !isString || (member != "type" && member != "id" && member != "lid") {
+			return false
+		}
+	}
+	_, hasType := obj["type"]
+	_, hasID := obj["id"]
+	_, hasLID := obj["lid"]
+	return hasType && (hasID || hasLID)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+func TestFlatten(t *testing.T) {
+	out, err := json.Marshal(flatten(testDoc(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"data":[{"author":{"id":"9","type":"people"},"comments":[{"id":"5","type":"comments"},{"id":"6","type":"comments"}],` +
+		`"id":"1","title":"Hello","type":"posts"}],"included":[{"id":"9","name":"Ann","type":"people"},` +
+		`{"author":{"id":"9","type":"people"},"body":"Hi","id":"5","post":{"id":"1","type":"posts"},"type":"comments"}]}`
+	if string(out) != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	flat, err := json.Marshal(flatten(testDoc(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := unflatten(flat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	many, ok := payload.(*jsonapi.ManyPayload)
+	if !ok || len(many.Data) != 1 || len(many.Included) != 2 {
+		t.Fatalf("got %+v", payload)
+	}
+	post := many.Data[0]
+	if post.Type != "posts" || post.ID != "1" || !reflect.DeepEqual(post.Attributes, map[string]interface{}{"title": "Hello"}) {
+		t.Fatalf("got post %+v", post)
+	}
+	want := map[string]interface{}{"data": map[string]interface{}{"type": "people", "id": "9"}}
+	if !reflect.DeepEqual(post.Relationships["author"], want) {
+		t.Fatalf("got author %v, want %v", post.Relationships["author"], want)
+	}
+	if len(post.Relationships) != 2 {
+		t.Fatalf("got relationships %v", post.Relationships)
+	}
+}
+
+func TestUnflatten_attributes(t *testing.T) {
+	payload, err := unflatten([]byte(`{"data":{"type":"posts","lid":"new","tags":[],"location":{"type":"point","x":1},` +
+		`"editor":null,"reviewers":[{"type":"people","lid":"a"}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := payload.(*jsonapi.OnePayload).Data
+	if post.LID != "new" || len(post.Attributes) != 3 || len(post.Relationships) != 1 || post.Relationships["reviewers"] == nil {
+		t.Fatalf("got %+v", post)
+	}
+}
+
+func TestUnflatten_invalid(t *testing.T) {
+	for _, in := range []string{`[]`, `{"data":1}`, `{"data":{"id":"1"}}`, `{"data":[1]}`, `{"data":null,"included":[{}]}`} {
+		if _, err := unflatten([]byte(in)); err == nil {
+			t.Errorf("%s: want an error", in)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Command jsonapi inspects JSON API documents, for debugging.
+//
+// Usage:
+//
+//	jsonapi resolve [document.json]
+//	jsonapi flatten [document.json]
+//	jsonapi unflatten [flat.json]
+//	jsonapi select -type posts [-id 1] [document.json]
+//
+// The document is read from stdin when no file is given, and the result is
+// written to stdout as indented JSON.
+//
+// resolve replaces the resource identifiers of the relationships of the
+// primary data with the resources they identify, from the included
+// resources, recursively; an identifier is kept when its resource isn't in
+// the document or is already being resolved, e.g. a post whose comments
+// point back at it. The included resources are dropped from the result.
+//
+// flatten converts a document to a plain JSON view: each resource object
+// becomes an object of its type, id, lid and attributes, with each
+// relationship as a member holding its resource identifiers, null, or an
+// array of identifiers. Links and meta are dropped, and relationships without
+// data are omitted:
+//
+//	{"data":{"type":"posts","id":"1","title":"Hello","author":{"type":"people","id":"9"}}}
+//
+// unflatten converts such a view back to a document. Members holding a
+// resource identifier, or a non-empty array of them, become relationships and
+// the others attributes.
+//
+// select prints the resources of a type, from the primary data and the
+// included resources, or the one with the given id.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/google/jsonapi"
+)
+
+// commands are the subcommands, which are given their arguments.
+var commands = map[string]func(args []string, stdin io.Reader, stdout io.Writer) error{
+	"resolve":   resolveCommand,
+	"flatten":   flattenCommand,
+	"unflatten": unflattenCommand,
+	"select":    selectCommand,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return usageError()
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return usageError()
+	}
+	return command(args[1:], stdin, stdout)
+}
+
+func usageError() error {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("usage: jsonapi %s [document.json]", strings.Join(names, "|"))
+}
+
+// readInput returns the content of the single file of files, or of stdin.
+func readInput(files []string, stdin io.Reader) ([]byte, error) {
+	switch len(files) {
+	case 0:
+		return ioutil.ReadAll(stdin)
+	case 1:
+		return ioutil.ReadFile(files[0])
+	}
+	return nil, fmt.Errorf("jsonapi: expected a single document, got %d", len(files))
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// decodeDocument returns the Document of the compound document in.
+func decodeDocument(in []byte) (*jsonapi.Document, error) {
+	doc, err := jsonapi.ReadDocument(bytes.NewReader(in))
+	if err == jsonapi.ErrNoPrimaryData {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jsonapi: invalid document: %v", err)
+	}
+	return doc, nil
+}
+
+// isMany reports whether the primary data of doc is an array.
+func isMany(doc *jsonapi.Document) bool {
+	_, many := doc.Payload().(*jsonapi.ManyPayload)
+	return many
+}
+
+// withData returns the payload of doc with data as its primary data, and
+// included as its included resources.
+func withData(doc *jsonapi.Document, data []*jsonapi.Node, included []*jsonapi.Node) jsonapi.Payloader {
+	switch p := doc.Payload().(type) {
+	case *jsonapi.ManyPayload:
+		return &jsonapi.ManyPayload{Data: data, Included: included, Links: p.Links, Meta: p.Meta}
+	case *jsonapi.OnePayload:
+		payload := &jsonapi.OnePayload{Included: included, Links: p.Links, Meta: p.Meta}
+		if len(data) > 0 {
+			payload.Data = data[0]
+		}
+		return payload
+	}
+	return nil
+}
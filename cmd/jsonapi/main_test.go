@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+const testDocument = `{
+	"data": [{
+		"type": "posts", "id": "1", "attributes": {"title": "Hello"},
+		"relationships": {
+			"author": {"data": {"type": "people", "id": "9"}, "links": {"related": "/posts/1/author"}},
+			"comments": {"data": [{"type": "comments", "id": "5"}, {"type": "comments", "id": "6"}]},
+			"tags": {"links": {"related": "/posts/1/tags"}}
+		}
+	}],
+	"included": [
+		{"type": "people", "id": "9", "attributes": {"name": "Ann"}},
+		{"type": "comments", "id": "5", "attributes": {"body": "Hi"},
+		 "relationships": {"post": {"data": {"type": "posts", "id": "1"}}, "author": {"data": {"type": "people", "id": "9"}}}}
+	],
+	"meta": {"total": 1}
+}`
+
+func testDoc(t *testing.T) *jsonapi.Document {
+	t.Helper()
+	doc, err := decodeDocument([]byte(testDocument))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestRun(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "document.json")
+	if err := ioutil.WriteFile(file, []byte(testDocument), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := run([]string{"select", "-type", "people", file}, nil, out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"name": "Ann"`) {
+		t.Fatalf("got %s", out)
+	}
+
+	out.Reset()
+	if err := run([]string{"flatten"}, strings.NewReader(testDocument), out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), `"title": "Hello"`) {
+		t.Fatalf("got %s", out)
+	}
+}
+
+func TestRun_nullResources(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		document string
+		want     string
+	}{
+		{[]string{"resolve"}, `{"data":[null]}`, `{"data":[null]}`},
+		{[]string{"resolve"}, `{"data":{"type":"a","id":"1"},"included":[null]}`, `{"data":{"type":"a","id":"1"}}`},
+		{[]string{"flatten"}, `{"data":[null]}`, `{"data":[null]}`},
+		{[]string{"flatten"}, `{"data":{"type":"a","id":"1"},"included":[null]}`, `{"data":{"id":"1","type":"a"},"included":[null]}`},
+		{[]string{"select", "-type", "a"}, `{"data":[null]}`, `{"data":[]}`},
+		{[]string{"select", "-type", "a"}, `{"data":{"type":"a","id":"1"},"included":[null]}`, `{"data":[{"type":"a","id":"1"}]}`},
+	} {
+		out := bytes.NewBuffer(nil)
+		if err := run(test.args, strings.NewReader(test.document), out); err != nil {
+			t.Errorf("%v %s: got error %v", test.args, test.document, err)
+			continue
+		}
+		if got := strings.Join(strings.Fields(out.String()), ""); got != test.want {
+			t.Errorf("%v %s: got %s, want %s", test.args, test.document, got, test.want)
+		}
+	}
+}
+
+func TestRun_usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"unknown"}} {
+		err := run(args, nil, nil)
+		if err == nil || !strings.Contains(err.Error(), "flatten|resolve|select|unflatten") {
+			t.Errorf("%v: got error %v, want the usage", args, err)
+		}
+	}
+}
+
+func TestDecodeDocument(t *testing.T) {
+	doc, err := decodeDocument([]byte(`{"data":{"type":"posts","id":"1"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isMany(doc) || len(doc.Data()) != 1 || doc.Data()[0].ID != "1" {
+		t.Fatalf("got %+v", doc)
+	}
+
+	doc, err = decodeDocument([]byte(`{"data":null}`))
+	if err != nil || len(doc.Data()) != 0 {
+		t.Fatalf("got %+v and error %v", doc, err)
+	}
+
+	for _, in := range []string{`{"errors":[]}`, `{"data":`, `{"data":1}`} {
+		if _, err := decodeDocument([]byte(in)); err == nil {
+			t.Errorf("%s: want an error", in)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io"
+
+	"github.com/google/jsonapi"
+)
+
+func resolveCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	in, err := readInput(args, stdin)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeDocument(in)
+	if err != nil {
+		return err
+	}
+	return writeJSON(stdout, withData(doc, resolve(doc), nil))
+}
+
+// resolve returns the primary data of doc with the linkage of its
+// relationships replaced by the resources it identifies. Null resources are
+// kept as they are.
+func resolve(doc *jsonapi.Document) []*jsonapi.Node {
+	r := &resolver{doc: doc, resources: map[*jsonapi.Node]bool{}, path: map[*jsonapi.Node]bool{}}
+	for _, n := range doc.Resources() {
+		if n != nil {
+			r.resources[n] = true
+		}
+	}
+
+	data := make([]*jsonapi.Node, len(doc.Data()))
+	for i, n := range doc.Data() {
+		if n != nil {
+			data[i] = r.node(n)
+		}
+	}
+	return data
+}
+
+// resolver inlines the resources of a document into the linkage identifying
+// them, as UnmarshalPayload does with fullNode.
+type resolver struct {
+	doc       *jsonapi.Document
+	resources map[*jsonapi.Node]bool
+	// path holds the resources being resolved, which are not inlined again
+	path map[*jsonapi.Node]bool
+}
+
+// node returns a copy of n whose relationships hold the resources they
+// identify.
+func (r *resolver) node(n *jsonapi.Node) *jsonapi.Node {
+	resolved := *n
+	if n.Relationships == nil {
+		return &resolved
+	}
+
+	r.path[n] = true
+	defer delete(r.path, n)
+
+	resolved.Relationships = make(map[string]interface{}, len(n.Relationships))
+	for name, value := range n.Relationships {
+		relationship, ok := value.(map[string]interface{})
+		if !ok {
+			resolved.Relationships[name] = value
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(relationship))
+		for member, v := range relationship {
+			copied[member] = v
+		}
+		// Related has a resource per object of the linkage, in order
+		related := r.doc.Related(n, name)
+		switch data := relationship["data"].(type) {
+		case map[string]interface{}:
+			copied["data"] = r.full(data, related[0])
+		case []interface{}:
+			nodes := make([]interface{}, len(data))
+			for i, d := range data {
+				nodes[i] = d
+				if _, isObject := d.(map[string]interface{}); isObject {
+					nodes[i] = r.full(d, related[0])
+					related = related[1:]
+				}
+			}
+			copied["data"] = nodes
+		}
+		resolved.Relationships[name] = copied
+	}
+	return &resolved
+}
+
+// full returns the resolved resource related, identified by value, or value
+// if the resource is not in the document or is being resolved.
+func (r *resolver) full(value interface{}, related *jsonapi.Node) interface{} {
+	if !r.resources[related] || r.path[related] {
+		return value
+	}
+	return r.node(related)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	data := resolve(testDoc(t))
+	if len(data) != 1 {
+		t.Fatalf("got %d resources", len(data))
+	}
+
+	out, err := json.Marshal(data[0].Relationships)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"author":{"data":{"type":"people","id":"9","attributes":{"name":"Ann"}},"links":{"related":"/posts/1/author"}},` +
+		`"comments":{"data":[{"type":"comments","id":"5","attributes":{"body":"Hi"},"relationships":{` +
+		`"author":{"data":{"type":"people","id":"9","attributes":{"name":"Ann"}}},` +
+		`"post":{"data":{"id":"1","type":"posts"}}}},` +
+		`{"id":"6","type":"comments"}]},` +
+		`"tags":{"links":{"related":"/posts/1/tags"}}}`
+	if string(out) != want {
+		t.Fatalf("got\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestResolve_keepsDocument(t *testing.T) {
+	doc := testDoc(t)
+	resolve(doc)
+
+	author := doc.Data()[0].Relationships["author"].(map[string]interface{})["data"]
+	if _, isIdentifier := author.(map[string]interface{}); !isIdentifier {
+		t.Fatalf("resolve modified the document: got author %v", author)
+	}
+}
+
+func TestResolveCommand(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := resolveCommand(nil, strings.NewReader(testDocument), out); err != nil {
+		t.Fatal(err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &payload); err != nil {
+		t.Fatal(err)
+	}
+	if _, hasIncluded := payload["included"]; hasIncluded {
+		t.Fatal("want the included resources to be dropped")
+	}
+	if payload["meta"] == nil {
+		t.Fatal("want the meta of the document")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/google/jsonapi"
+)
+
+func selectCommand(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("jsonapi select", flag.ContinueOnError)
+	resourceType := flags.String("type", "", "type of the resources to print")
+	id := flags.String("id", "", "id of the resource to print")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *resourceType == "" {
+		return fmt.Errorf("jsonapi select: -type is required")
+	}
+
+	in, err := readInput(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	doc, err := decodeDocument(in)
+	if err != nil {
+		return err
+	}
+
+	nodes := selectNodes(doc, *resourceType, *id)
+	if *id == "" {
+		return writeJSON(stdout, &jsonapi.ManyPayload{Data: nodes})
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("jsonapi select: the document has no resource %s,%s", *resourceType, *id)
+	}
+	return writeJSON(stdout, &jsonapi.OnePayload{Data: nodes[0]})
+}
+
+// selectNodes returns the resources of doc of type resourceType, and of the
+// given id unless it is empty, from its primary data then its included
+// resources. Null resources are skipped.
+func selectNodes(doc *jsonapi.Document, resourceType, id string) []*jsonapi.Node {
+	nodes := []*jsonapi.Node{}
+	for _, n := range doc.Resources() {
+		if n != nil && n.Type == resourceType && (id == "" || n.ID == id) {
+			nodes = append(nodes, n)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+func TestSelectNodes(t *testing.T) {
+	doc := testDoc(t)
+
+	if nodes := selectNodes(doc, "comments", ""); len(nodes) != 1 || nodes[0].ID != "5" {
+		t.Fatalf("got %v, want the included comment", nodes)
+	}
+	if nodes := selectNodes(doc, "posts", "1"); len(nodes) != 1 || nodes[0] != doc.Data()[0] {
+		t.Fatalf("got %v, want the primary data", nodes)
+	}
+	if nodes := selectNodes(doc, "people", "1"); len(nodes) != 0 {
+		t.Fatalf("got %v", nodes)
+	}
+}
+
+func TestSelectCommand(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := selectCommand([]string{"-type", "people", "-id", "9"}, bytes.NewReader([]byte(testDocument)), out); err != nil {
+		t.Fatal(err)
+	}
+	payload := new(jsonapi.OnePayload)
+	if err := json.Unmarshal(out.Bytes(), payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data == nil || payload.Data.Attributes["name"] != "Ann" {
+		t.Fatalf("got %s", out)
+	}
+
+	if err := selectCommand([]string{"-type", "people", "-id", "1"}, bytes.NewReader([]byte(testDocument)), out); err == nil {
+		t.Fatal("want an error for a missing resource")
+	}
+	if err := selectCommand(nil, bytes.NewReader([]byte(testDocument)), out); err == nil {
+		t.Fatal("want an error without -type")
+	}
+}
@@ -5,6 +5,7 @@ const (
 	annotationJSONAPI   = "jsonapi"
 	annotationPrimary   = "primary"
 	annotationClientID  = "client-id"
+	annotationLID       = "lid"
 	annotationAttribute = "attr"
 	annotationRelation  = "relation"
 	annotationOmitEmpty = "omitempty"
@@ -19,6 +20,22 @@ const (
 	// see http://jsonapi.org/format/#document-structure
 	MediaType = "application/vnd.api+json"
 
+	// QueryParamInclude is a JSON API query parameter used to request related
+	// resources be included in a compound document
+	//
+	// http://jsonapi.org/format/#fetching-includes
+	QueryParamInclude = "include"
+	// QueryParamSort is a JSON API query parameter used to request the primary
+	// data be sorted by one or more sort fields
+	//
+	// http://jsonapi.org/format/#fetching-sorting
+	QueryParamSort = "sort"
+	// QueryParamFields is a JSON API query parameter used to request only some
+	// fields of the resources of a type, e.g. "fields[posts]=title,body"
+	//
+	// http://jsonapi.org/format/#fetching-sparse-fieldsets
+	QueryParamFields = "fields"
+
 	// Pagination Constants
 	//
 	// http://jsonapi.org/format/#fetching-pagination
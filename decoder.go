@@ -0,0 +1,186 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+const (
+	memberData     = "data"
+	memberIncluded = "included"
+)
+
+// ErrInvalidDocument is returned by a Decoder when the document is not a
+// JSON object whose "data" member is an array of resource objects.
+var ErrInvalidDocument = errors.New("jsonapi: document should be an object with an array of resource objects as data")
+
+// Decoder reads the primary resources of a compound document from a stream
+// one at a time, so that documents too large to be held in memory, e.g.
+// multi-gigabyte dumps, can be processed. Only the "included" resources are
+// held in memory.
+//
+//	dec := jsonapi.NewDecoder(r, reflect.TypeOf(new(Blog)))
+//	err := dec.Decode(func(model interface{}) error {
+//		blog := model.(*Blog)
+//		// ...import blog...
+//		return nil
+//	})
+//
+// When "included" comes before "data", relationships are populated from the
+// included resources as with UnmarshalManyPayload. Otherwise related
+// resources only have their ids, unless ResolveIncluded is set.
+type Decoder struct {
+	// ResolveIncluded, if set, holds back the primary resources read before
+	// "included" whose relationships have resource linkage, and passes them
+	// with their relationships populated once "included" has been read, at
+	// the end of the document. Resources without linkage are passed as soon
+	// as they are read. The held back models, and their resource linkage,
+	// stay in memory until then, so memory grows with the number of linked
+	// resources that precede "included".
+	ResolveIncluded bool
+
+	dec *json.Decoder
+	t   reflect.Type
+}
+
+// NewDecoder returns a Decoder reading a document from r, whose resources are
+// unmarshalled into struct pointers of type t. Decode returns
+// ErrUnexpectedType if t is not a struct pointer type.
+func NewDecoder(r io.Reader, t reflect.Type) *Decoder {
+	return &Decoder{dec: json.NewDecoder(r), t: t}
+}
+
+// Decode reads the document and calls fn with each primary resource, in
+// document order except for the resources held back by ResolveIncluded. It
+// stops at the first error, including those returned by fn.
+func (d *Decoder) Decode(fn func(model interface{}) error) error {
+	if d.t == nil || d.t.Kind() != reflect.Ptr || d.t.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+	if err := d.expectDelim('{'); err != nil {
+		return err
+	}
+
+	var included map[string]*Node
+	var buffered []pendingModel
+	yield := func(node *Node) error {
+		model := reflect.New(d.t.Elem())
+		if err := unmarshalNode(node, model, &included); err != nil {
+			return err
+		}
+		if included == nil && d.ResolveIncluded && hasLinkage(node) {
+			buffered = append(buffered, pendingModel{model: model, linkage: linkageOnly(node)})
+			return nil
+		}
+		return fn(model.Interface())
+	}
+
+	for d.dec.More() {
+		token, err := d.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token {
+		case memberData:
+			if err := d.expectDelim('['); err != nil {
+				return err
+			}
+			for d.dec.More() {
+				node := new(Node)
+				if err := d.dec.Decode(node); err != nil {
+					return err
+				}
+				if err := yield(node); err != nil {
+					return err
+				}
+			}
+			if err := d.expectDelim(']'); err != nil {
+				return err
+			}
+		case memberIncluded:
+			nodes := []*Node{}
+			if err := d.dec.Decode(&nodes); err != nil {
+				return err
+			}
+			included = make(map[string]*Node, len(nodes))
+			for _, n := range nodes {
+				included[nodeKey(n)] = n
+			}
+		default:
+			var skipped json.RawMessage
+			if err := d.dec.Decode(&skipped); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := d.expectDelim('}'); err != nil {
+		return err
+	}
+
+	for _, pending := range buffered {
+		if err := unmarshalNode(pending.linkage, pending.model, &included); err != nil {
+			return err
+		}
+		if err := fn(pending.model.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) expectDelim(delim json.Delim) error {
+	token, err := d.dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("%w: unexpected %v", ErrInvalidDocument, token)
+	}
+	return nil
+}
+
+// pendingModel is a primary resource held back by ResolveIncluded until the
+// included resources have been read.
+type pendingModel struct {
+	model   reflect.Value
+	linkage *Node
+}
+
+// linkageOnly returns the identification and relationships of node, which
+// are all that is needed to populate the relationships of its model once the
+// included resources are known.
+func linkageOnly(node *Node) *Node {
+	return &Node{
+		Type:          node.Type,
+		ID:            node.ID,
+		ClientID:      node.ClientID,
+		LID:           node.LID,
+		Relationships: node.Relationships,
+	}
+}
+
+// hasLinkage reports whether any relationship of node has resource linkage
+// that included resources could complete.
+func hasLinkage(node *Node) bool {
+	for _, rel := range node.Relationships {
+		relationship, ok := rel.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch data := relationship[memberData].(type) {
+		case map[string]interface{}:
+			return true
+		case []interface{}:
+			if len(data) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package jsonapi
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testStreamIncludedLast = `{
+	"meta": {"total": 3},
+	"data": [
+		{"type": "posts", "id": "1", "attributes": {"title": "One"},
+			"relationships": {"latest_comment": {"data": {"type": "comments", "id": "7"}}}},
+		{"type": "posts", "id": "2", "attributes": {"title": "Two"}},
+		{"type": "posts", "id": "3", "attributes": {"title": "Three"},
+			"relationships": {"comments": {"data": []}}}
+	],
+	"included": [
+		{"type": "comments", "id": "7", "attributes": {"body": "Seven"}}
+	]
+}`
+
+func decodePosts(t *testing.T, dec *Decoder) []*Post {
+	posts := []*Post{}
+	if err := dec.Decode(func(model interface{}) error {
+		posts = append(posts, model.(*Post))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return posts
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(testStreamIncludedLast), reflect.TypeOf(new(Post)))
+	posts := decodePosts(t, dec)
+
+	if len(posts) != 3 {
+		t.Fatalf("got %d posts, want 3", len(posts))
+	}
+	if posts[0].ID != 1 || posts[0].Title != "One" || posts[2].Title != "Three" {
+		t.Fatalf("got %+v, want the posts in document order", posts)
+	}
+	if posts[0].LatestComment == nil || posts[0].LatestComment.ID != 7 {
+		t.Fatalf("got %+v, want the linked comment id", posts[0].LatestComment)
+	}
+	if posts[0].LatestComment.Body != "" {
+		t.Fatal("Included resources after data should not be resolved by default")
+	}
+}
+
+func TestDecoder_resolveIncluded(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(testStreamIncludedLast), reflect.TypeOf(new(Post)))
+	dec.ResolveIncluded = true
+	posts := decodePosts(t, dec)
+
+	if len(posts) != 3 {
+		t.Fatalf("got %d posts, want 3", len(posts))
+	}
+	// Posts without linkage are not buffered
+	if posts[0].ID != 2 || posts[1].ID != 3 || posts[2].ID != 1 {
+		t.Fatalf("got %+v, want post 1 last", posts)
+	}
+	if posts[2].Title != "One" || posts[2].LatestComment.Body != "Seven" {
+		t.Fatalf("got %+v, want the attributes and the included comment", posts[2])
+	}
+}
+
+func TestDecoder_unexpectedType(t *testing.T) {
+	for _, typ := range []reflect.Type{nil, reflect.TypeOf(Post{}), reflect.TypeOf(new(string))} {
+		dec := NewDecoder(strings.NewReader(testStreamIncludedLast), typ)
+		if err := dec.Decode(func(interface{}) error { return nil }); err != ErrUnexpectedType {
+			t.Fatalf("%v: got %v, want %v", typ, err, ErrUnexpectedType)
+		}
+	}
+}
+
+func TestDecoder_includedFirst(t *testing.T) {
+	payload := `{
+		"included": [{"type": "comments", "id": "7", "attributes": {"body": "Seven"}}],
+		"data": [{"type": "posts", "id": "1",
+			"relationships": {"latest_comment": {"data": {"type": "comments", "id": "7"}}}}]
+	}`
+
+	posts := decodePosts(t, NewDecoder(strings.NewReader(payload), reflect.TypeOf(new(Post))))
+	if len(posts) != 1 || posts[0].LatestComment.Body != "Seven" {
+		t.Fatalf("got %+v, want the included comment", posts)
+	}
+}
+
+func TestDecoder_callbackError(t *testing.T) {
+	stop := errors.New("stop")
+	var calls int
+
+	dec := NewDecoder(strings.NewReader(testStreamIncludedLast), reflect.TypeOf(new(Post)))
+	err := dec.Decode(func(model interface{}) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Fatalf("got %v after %d calls, want %v after 1", err, calls, stop)
+	}
+}
+
+func TestDecoder_invalidDocument(t *testing.T) {
+	dec := NewDecoder(strings.NewReader(`{"data": {"type": "posts", "id": "1"}}`), reflect.TypeOf(new(Post)))
+	err := dec.Decode(func(model interface{}) error { return nil })
+	if !errors.Is(err, ErrInvalidDocument) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidDocument)
+	}
+}
@@ -0,0 +1,452 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const documentErrorTitle = "Invalid Document"
+
+// DocumentError is returned by UnmarshalPayloadWithOptions with the
+// WithDocumentValidation option when the document is not a valid JSON API
+// document, see ValidateDocument.
+type DocumentError struct {
+	Errors []*ErrorObject
+}
+
+// Error implements the `Error` interface.
+func (e *DocumentError) Error() string {
+	details := make([]string, len(e.Errors))
+	for i, obj := range e.Errors {
+		details[i] = fmt.Sprintf("%s: %s", obj.Source.Pointer, obj.Detail)
+	}
+	return fmt.Sprintf("jsonapi: invalid document: %s", strings.Join(details, "; "))
+}
+
+// UnmarshalOption configures UnmarshalPayloadWithOptions.
+type UnmarshalOption func(*unmarshalConfig)
+
+type unmarshalConfig struct {
+	validateDocument bool
+}
+
+// WithDocumentValidation checks the document with ValidateDocument before
+// unmarshalling it, and returns a *DocumentError if it is invalid.
+func WithDocumentValidation() UnmarshalOption {
+	return func(c *unmarshalConfig) {
+		c.validateDocument = true
+	}
+}
+
+// ValidateDocument checks a document against the structural rules of the
+// JSON API specification: the members allowed at the top level and in
+// resource objects, resource identifier objects, relationships, links and
+// error objects, the types of their values, and member names. Extension
+// members, whose names contain a colon, and @-members are accepted, as is
+// the "client-id" member of resource objects supported by this package.
+//
+// It returns an error object per violation with a 400 Bad Request status
+// and a pointer to the offending value, e.g. "/data/attributes/id"; nil
+// means the document is valid.
+//
+// http://jsonapi.org/format/#document-structure
+func ValidateDocument(in io.Reader) []*ErrorObject {
+	dec := json.NewDecoder(in)
+	dec.UseNumber()
+
+	var document interface{}
+	if err := dec.Decode(&document); err != nil {
+		return []*ErrorObject{documentError("", fmt.Sprintf("The document is not valid JSON: %s", err))}
+	}
+
+	v := &documentValidator{errs: []*ErrorObject{}}
+	v.topLevel(document)
+
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// documentValidator collects the violations of a decoded document.
+type documentValidator struct {
+	errs []*ErrorObject
+}
+
+func (v *documentValidator) fail(pointer, format string, args ...interface{}) {
+	v.errs = append(v.errs, documentError(pointer, fmt.Sprintf(format, args...)))
+}
+
+// object returns value as an object, or fails if it is not one.
+func (v *documentValidator) object(pointer string, value interface{}, what string) (map[string]interface{}, bool) {
+	obj, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(pointer, "%s must be an object", what)
+	}
+	return obj, ok
+}
+
+// members checks the member names of obj against the allowed ones, and
+// calls check for each allowed member in name order.
+func (v *documentValidator) members(pointer string, obj map[string]interface{}, what string, allowed map[string]func(string, interface{})) {
+	for _, name := range sortedKeys(obj) {
+		if isExtensionMember(name) {
+			continue
+		}
+		check, ok := allowed[name]
+		if !ok {
+			v.fail(pointer+"/"+escapePointerToken(name), "%q is not a member of %s", name, what)
+			continue
+		}
+		if check != nil {
+			check(pointer+"/"+escapePointerToken(name), obj[name])
+		}
+	}
+}
+
+func (v *documentValidator) topLevel(document interface{}) {
+	doc, ok := v.object("", document, "A document")
+	if !ok {
+		return
+	}
+
+	_, hasData := doc[memberData]
+	_, hasErrors := doc["errors"]
+	_, hasMeta := doc["meta"]
+	switch {
+	case !hasData && !hasErrors && !hasMeta:
+		v.fail("", "A document must contain at least one of data, errors or meta")
+	case hasData && hasErrors:
+		v.fail("", "A document must not contain both data and errors")
+	}
+	if _, hasIncluded := doc[memberIncluded]; hasIncluded && !hasData {
+		v.fail("/"+memberIncluded, "A document without data must not contain included")
+	}
+
+	v.members("", doc, "a document", map[string]func(string, interface{}){
+		memberData:     v.primaryData,
+		memberIncluded: v.included,
+		"errors":       v.errorObjects,
+		"meta":         v.meta,
+		"links":        v.links,
+		"jsonapi":      v.jsonapiObject,
+	})
+}
+
+func (v *documentValidator) primaryData(pointer string, data interface{}) {
+	switch data := data.(type) {
+	case nil:
+	case []interface{}:
+		for i, resource := range data {
+			v.resource(pointer+"/"+strconv.Itoa(i), resource)
+		}
+	default:
+		v.resource(pointer, data)
+	}
+}
+
+func (v *documentValidator) included(pointer string, included interface{}) {
+	resources, ok := included.([]interface{})
+	if !ok {
+		v.fail(pointer, "included must be an array of resource objects")
+		return
+	}
+	for i, resource := range resources {
+		v.resource(pointer+"/"+strconv.Itoa(i), resource)
+	}
+}
+
+func (v *documentValidator) resource(pointer string, value interface{}) {
+	resource, ok := v.object(pointer, value, "A resource object")
+	if !ok {
+		return
+	}
+
+	v.identity(pointer, resource)
+	v.members(pointer, resource, "a resource object", map[string]func(string, interface{}){
+		"type":             nil,
+		"id":               nil,
+		"lid":              nil,
+		annotationClientID: v.str,
+		"attributes":       v.attributes,
+		"relationships":    v.relationships,
+		"links":            v.links,
+		"meta":             v.meta,
+	})
+
+	// Attributes and relationships share a namespace
+	attributes, _ := resource["attributes"].(map[string]interface{})
+	relationships, _ := resource["relationships"].(map[string]interface{})
+	for _, name := range sortedKeys(relationships) {
+		if _, clash := attributes[name]; clash {
+			v.fail(pointer+"/relationships/"+escapePointerToken(name),
+				"%q is both an attribute and a relationship", name)
+		}
+	}
+}
+
+// identity checks the type, id and lid members of a resource object or
+// resource identifier object.
+func (v *documentValidator) identity(pointer string, obj map[string]interface{}) {
+	if t, ok := obj["type"].(string); !ok || t == "" {
+		v.fail(pointer+"/type", "type must be a non-empty string")
+	}
+	for _, member := range []string{"id", "lid"} {
+		if value, exists := obj[member]; exists {
+			if _, ok := value.(string); !ok {
+				v.fail(pointer+"/"+member, "%s must be a string", member)
+			}
+		}
+	}
+}
+
+func (v *documentValidator) attributes(pointer string, value interface{}) {
+	attributes, ok := v.object(pointer, value, "attributes")
+	if !ok {
+		return
+	}
+
+	for _, name := range sortedKeys(attributes) {
+		memberPointer := pointer + "/" + escapePointerToken(name)
+		switch {
+		case isExtensionMember(name):
+		case isReservedAttributeName(name):
+			v.fail(memberPointer, "%q can't be used as an attribute name", name)
+		case !isValidMemberName(name):
+			v.fail(memberPointer, "%q is not a valid member name", name)
+		}
+	}
+}
+
+func (v *documentValidator) relationships(pointer string, value interface{}) {
+	relationships, ok := v.object(pointer, value, "relationships")
+	if !ok {
+		return
+	}
+
+	for _, name := range sortedKeys(relationships) {
+		relPointer := pointer + "/" + escapePointerToken(name)
+		switch {
+		case isExtensionMember(name):
+			continue
+		case isReservedFieldName(name):
+			v.fail(relPointer, "%q can't be used as a field name", name)
+		case !isValidMemberName(name):
+			v.fail(relPointer, "%q is not a valid member name", name)
+		}
+
+		relationship, ok := v.object(relPointer, relationships[name], "A relationship")
+		if !ok {
+			continue
+		}
+		_, hasData := relationship[memberData]
+		_, hasLinks := relationship["links"]
+		_, hasMeta := relationship["meta"]
+		if !hasData && !hasLinks && !hasMeta {
+			v.fail(relPointer, "A relationship must contain at least one of data, links or meta")
+		}
+		v.members(relPointer, relationship, "a relationship", map[string]func(string, interface{}){
+			memberData: v.linkage,
+			"links":    v.links,
+			"meta":     v.meta,
+		})
+	}
+}
+
+func (v *documentValidator) linkage(pointer string, data interface{}) {
+	switch data := data.(type) {
+	case nil:
+	case []interface{}:
+		for i, identifier := range data {
+			v.identifier(pointer+"/"+strconv.Itoa(i), identifier)
+		}
+	default:
+		v.identifier(pointer, data)
+	}
+}
+
+func (v *documentValidator) identifier(pointer string, value interface{}) {
+	identifier, ok := v.object(pointer, value, "A resource identifier object")
+	if !ok {
+		return
+	}
+
+	v.identity(pointer, identifier)
+	if _, hasID := identifier["id"]; !hasID {
+		if _, hasLID := identifier["lid"]; !hasLID {
+			v.fail(pointer, "A resource identifier object must contain id or lid")
+		}
+	}
+	v.members(pointer, identifier, "a resource identifier object", map[string]func(string, interface{}){
+		"type": nil,
+		"id":   nil,
+		"lid":  nil,
+		"meta": v.meta,
+	})
+}
+
+func (v *documentValidator) links(pointer string, value interface{}) {
+	links, ok := v.object(pointer, value, "links")
+	if !ok {
+		return
+	}
+
+	for _, name := range sortedKeys(links) {
+		linkPointer := pointer + "/" + escapePointerToken(name)
+		switch link := links[name].(type) {
+		case nil, string:
+		case map[string]interface{}:
+			if _, ok := link["href"].(string); !ok {
+				v.fail(linkPointer+"/href", "A link object must contain an href string")
+			}
+		default:
+			v.fail(linkPointer, "A link must be a string, a link object or null")
+		}
+	}
+}
+
+func (v *documentValidator) meta(pointer string, value interface{}) {
+	v.object(pointer, value, "meta")
+}
+
+func (v *documentValidator) str(pointer string, value interface{}) {
+	if _, ok := value.(string); !ok {
+		v.fail(pointer, "The value must be a string")
+	}
+}
+
+func (v *documentValidator) jsonapiObject(pointer string, value interface{}) {
+	obj, ok := v.object(pointer, value, "The jsonapi member")
+	if !ok {
+		return
+	}
+	v.members(pointer, obj, "the jsonapi object", map[string]func(string, interface{}){
+		"version": v.str,
+		"ext":     v.uris,
+		"profile": v.uris,
+		"meta":    v.meta,
+	})
+}
+
+func (v *documentValidator) uris(pointer string, value interface{}) {
+	uris, ok := value.([]interface{})
+	if !ok {
+		v.fail(pointer, "The value must be an array of URIs")
+		return
+	}
+	for i, uri := range uris {
+		v.str(pointer+"/"+strconv.Itoa(i), uri)
+	}
+}
+
+func (v *documentValidator) errorObjects(pointer string, value interface{}) {
+	objects, ok := value.([]interface{})
+	if !ok {
+		v.fail(pointer, "errors must be an array of error objects")
+		return
+	}
+
+	for i, value := range objects {
+		objPointer := pointer + "/" + strconv.Itoa(i)
+		obj, ok := v.object(objPointer, value, "An error object")
+		if !ok {
+			continue
+		}
+		v.members(objPointer, obj, "an error object", map[string]func(string, interface{}){
+			"id":     v.str,
+			"links":  v.links,
+			"status": v.str,
+			"code":   v.str,
+			"title":  v.str,
+			"detail": v.str,
+			"source": v.errorSource,
+			"meta":   v.meta,
+		})
+	}
+}
+
+func (v *documentValidator) errorSource(pointer string, value interface{}) {
+	source, ok := v.object(pointer, value, "An error source")
+	if !ok {
+		return
+	}
+	v.members(pointer, source, "an error source", map[string]func(string, interface{}){
+		"pointer":   v.str,
+		"parameter": v.str,
+		"header":    v.str,
+	})
+}
+
+// isExtensionMember reports whether name is the name of an extension member,
+// e.g. "atomic:operations", or an @-member, which are not defined by the
+// base specification.
+func isExtensionMember(name string) bool {
+	return strings.Contains(name, ":") || strings.HasPrefix(name, "@")
+}
+
+// isReservedFieldName reports whether name can't be used for an attribute or
+// relationship because it is reserved by the resource object.
+//
+// http://jsonapi.org/format/#document-resource-object-fields
+func isReservedFieldName(name string) bool {
+	return name == "id" || name == "type"
+}
+
+// isReservedAttributeName reports whether name can't be used for an
+// attribute, which additionally can't be named like the members holding
+// relationships and links.
+//
+// http://jsonapi.org/format/#document-resource-object-attributes
+func isReservedAttributeName(name string) bool {
+	return isReservedFieldName(name) || name == "relationships" || name == "links"
+}
+
+// isValidMemberName reports whether name is a legal member name: at least
+// one character, letters, digits and non-ASCII characters, and hyphens,
+// underscores and spaces except at the start and end.
+//
+// http://jsonapi.org/format/#document-member-names
+func isValidMemberName(name string) bool {
+	runes := []rune(name)
+	if len(runes) == 0 {
+		return false
+	}
+
+	for i, r := range runes {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r >= 0x80:
+		case (r == '-' || r == '_' || r == ' ') && i > 0 && i < len(runes)-1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func documentError(pointer, detail string) *ErrorObject {
+	return &ErrorObject{
+		Title:  documentErrorTitle,
+		Detail: detail,
+		Status: strconv.Itoa(http.StatusBadRequest),
+		Source: &ErrorSource{Pointer: pointer},
+	}
+}
+
+// validatedReader validates the document read from in and returns a reader
+// of its content, or a *DocumentError.
+func validatedReader(in io.Reader) (io.Reader, error) {
+	b, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	if errs := ValidateDocument(bytes.NewReader(b)); errs != nil {
+		return nil, &DocumentError{Errors: errs}
+	}
+	return bytes.NewReader(b), nil
+}
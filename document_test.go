@@ -0,0 +1,128 @@
+package jsonapi
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// UnmarshalPayload keeps its signature; options go through
+// UnmarshalPayloadWithOptions
+var _ func(io.Reader, interface{}) error = UnmarshalPayload
+
+func documentPointers(errs []*ErrorObject) string {
+	pointers := []string{}
+	for _, e := range errs {
+		pointers = append(pointers, e.Source.Pointer)
+	}
+	return strings.Join(pointers, " ")
+}
+
+func TestValidateDocument_valid(t *testing.T) {
+	for _, document := range []string{
+		`{"data": null}`,
+		`{"meta": {"total": 0}}`,
+		`{"errors": [{"status": "404", "source": {"pointer": "/data"}}]}`,
+		`{"data": [], "links": {"self": "/posts", "next": {"href": "/posts?page=2"}, "prev": null}}`,
+		`{"atomic:operations": [], "meta": {}}`,
+		`{
+			"jsonapi": {"version": "1.1", "ext": ["https://jsonapi.org/ext/atomic"]},
+			"data": {
+				"type": "posts", "lid": "new", "client-id": "abc",
+				"attributes": {"title": "Hi", "comment count": 1, "naïve": true},
+				"relationships": {
+					"comments": {"data": [{"type": "comments", "id": "1"}]},
+					"author": {"links": {"related": "/posts/1/author"}}
+				}
+			},
+			"included": [{"type": "comments", "id": "1"}]
+		}`,
+	} {
+		if errs := ValidateDocument(strings.NewReader(document)); errs != nil {
+			t.Errorf("%s: got violations at %s, want none", document, documentPointers(errs))
+		}
+	}
+}
+
+func TestValidateDocument_marshalled(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, testIncludedBlog()); err != nil {
+		t.Fatal(err)
+	}
+	if errs := ValidateDocument(out); errs != nil {
+		t.Fatalf("got violations at %s, want none", documentPointers(errs))
+	}
+}
+
+func TestValidateDocument_violations(t *testing.T) {
+	for document, want := range map[string]string{
+		`[]`:                                      "",
+		`{"data": `:                               "",
+		`{"links": {}}`:                           "",
+		`{"data": null, "errors": []}`:            "",
+		`{"meta": {}, "included": []}`:            "/included",
+		`{"data": null, "unknown": 1}`:            "/unknown",
+		`{"data": {"id": 1}}`:                     "/data/type /data/id",
+		`{"data": [{"type": "posts"}, "posts"]}`:  "/data/1",
+		`{"errors": [{"status": 404}]}`:           "/errors/0/status",
+		`{"data": null, "links": {"self": 1}}`:    "/links/self",
+		`{"data": null, "links": {"self": {}}}`:   "/links/self/href",
+		`{"data": null, "jsonapi": {"foo": "1"}}`: "/jsonapi/foo",
+		`{"data": {"type": "posts", "attributes": {"id": 1, "links": 2, "-bad": 3, "ok_name": 4}}}`:                "/data/attributes/-bad /data/attributes/id /data/attributes/links",
+		`{"data": {"type": "posts", "relationships": {"author": {}}}}`:                                             "/data/relationships/author",
+		`{"data": {"type": "posts", "relationships": {"type": {"data": null}}}}`:                                   "/data/relationships/type",
+		`{"data": {"type": "posts", "relationships": {"author": {"data": {"type": "people"}}}}}`:                   "/data/relationships/author/data",
+		`{"data": {"type": "posts", "attributes": {"a": 1}, "relationships": {"a": {"meta": {}}}}}`:                "/data/relationships/a",
+		`{"data": {"type": "posts", "relationships": {"a~b": {"data": [{"type": "x", "id": "1", "name": "y"}]}}}}`: "/data/relationships/a~0b /data/relationships/a~0b/data/0/name",
+	} {
+		errs := ValidateDocument(strings.NewReader(document))
+		if got := documentPointers(errs); errs == nil || got != want {
+			t.Errorf("%s: got violations at %q, want %q", document, got, want)
+		}
+		for _, e := range errs {
+			if e.Status != "400" {
+				t.Errorf("%s: got status %s, want 400", document, e.Status)
+			}
+		}
+	}
+}
+
+func TestIsValidMemberName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"title":      true,
+		"created_at": true,
+		"first-name": true,
+		"a b":        true,
+		"café":       true,
+		"":           false,
+		"_private":   false,
+		"trailing-":  false,
+		"a.b":        false,
+		"a/b":        false,
+	} {
+		if got := isValidMemberName(name); got != want {
+			t.Errorf("isValidMemberName(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestUnmarshalPayload_withDocumentValidation(t *testing.T) {
+	out := new(Blog)
+	if err := UnmarshalPayloadWithOptions(samplePayloadWithSideloaded(), out, WithDocumentValidation()); err != nil {
+		t.Fatal(err)
+	}
+	if out.Title != "Title 1" {
+		t.Fatalf("got %+v, want the sample blog", out)
+	}
+
+	invalid := `{"data": {"type": "blogs", "id": 5, "attributes": {"title": "New"}}, "errors": []}`
+	err := UnmarshalPayloadWithOptions(strings.NewReader(invalid), new(Blog), WithDocumentValidation())
+	docErr, ok := err.(*DocumentError)
+	if !ok {
+		t.Fatalf("got %v, want a *DocumentError", err)
+	}
+	if got := documentPointers(docErr.Errors); got != " /data/id" {
+		t.Fatalf("got violations at %q, want the root and /data/id", got)
+	}
+}
@@ -0,0 +1,189 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"reflect"
+)
+
+// ErrEncoderClosed is returned when writing to an Encoder that was closed.
+var ErrEncoderClosed = errors.New("jsonapi: encoder is closed")
+
+// Encoder writes a compound document of many resources to a stream one
+// resource at a time, so that collections too large to be held in memory,
+// e.g. exports of millions of rows, can be written. Only the related
+// resources are kept until the document is closed, encoded and deduplicated,
+// to be written in the "included" member.
+//
+//	enc := jsonapi.NewEncoder(w)
+//	for rows.Next() {
+//		blog := new(Blog)
+//		// ...scan the row into blog...
+//		if err := enc.Encode(blog); err != nil {
+//			return err
+//		}
+//	}
+//	return enc.Close()
+type Encoder struct {
+	// Links, if set before Close, are written as the document's top-level
+	// links.
+	Links *Links
+	// Meta, if set before Close, is written as the document's top-level meta.
+	Meta *Meta
+
+	w        io.Writer
+	started  bool
+	closed   bool
+	included *bytes.Buffer
+	seen     map[string]bool
+}
+
+// NewEncoder returns an Encoder writing a document to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, included: bytes.NewBuffer(nil), seen: map[string]bool{}}
+}
+
+// Encode writes model, a struct pointer, as the next element of the
+// document's primary data, and keeps its related resources to be included.
+// ErrUnexpectedType is returned if model is not a non-nil struct pointer.
+func (e *Encoder) Encode(model interface{}) error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	if v := reflect.ValueOf(model); v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return ErrUnexpectedType
+	}
+
+	included := newIncludedNodes()
+	node, err := visitModelNode(model, included, true)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range included.values() {
+		key := nodeKey(n)
+		if e.seen[key] {
+			continue
+		}
+		e.seen[key] = true
+
+		b, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		if e.included.Len() > 0 {
+			e.included.WriteByte(',')
+		}
+		e.included.Write(b)
+	}
+
+	prefix := []byte(",")
+	if !e.started {
+		prefix = []byte(`{"data":[`)
+		e.started = true
+	}
+	if _, err := e.w.Write(append(prefix, data...)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// EncodeAll encodes the models received from models until it is closed, and
+// returns the first error, see Encode.
+func (e *Encoder) EncodeAll(models <-chan interface{}) error {
+	for model := range models {
+		if err := e.Encode(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncodeSeq encodes the models of the sequence models, and returns the first
+// error, see Encode. The sequence is stopped on error.
+func (e *Encoder) EncodeSeq(models iter.Seq[interface{}]) error {
+	for model := range models {
+		if err := e.Encode(model); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the included resources and the rest of the document. An empty
+// document, with an empty array as primary data, is written if nothing was
+// encoded.
+func (e *Encoder) Close() error {
+	if e.closed {
+		return ErrEncoderClosed
+	}
+	e.closed = true
+
+	out := bytes.NewBuffer(nil)
+	if !e.started {
+		out.WriteString(`{"data":[`)
+	}
+	out.WriteByte(']')
+
+	if e.included.Len() > 0 {
+		out.WriteString(`,"included":[`)
+		out.Write(e.included.Bytes())
+		out.WriteByte(']')
+	}
+	if e.Links != nil {
+		if err := writeMember(out, "links", e.Links); err != nil {
+			return err
+		}
+	}
+	if e.Meta != nil {
+		if err := writeMember(out, "meta", e.Meta); err != nil {
+			return err
+		}
+	}
+	out.WriteString("}\n")
+
+	e.included = nil
+	_, err := e.w.Write(out.Bytes())
+	return err
+}
+
+// MarshalStream writes a document of the models received from models until
+// it is closed, see Encoder. On error the document is left incomplete, and
+// the caller is responsible for stopping the sender of models.
+func MarshalStream(w io.Writer, models <-chan interface{}) error {
+	enc := NewEncoder(w)
+	if err := enc.EncodeAll(models); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// MarshalSeq writes a document of the models of the sequence models, see
+// Encoder. On error the sequence is stopped and the document is left
+// incomplete.
+func MarshalSeq(w io.Writer, models iter.Seq[interface{}]) error {
+	enc := NewEncoder(w)
+	if err := enc.EncodeSeq(models); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// writeMember writes a ,"name":value member of a JSON object.
+func writeMember(out *bytes.Buffer, name string, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	out.WriteString(`,"` + name + `":`)
+	out.Write(b)
+	return nil
+}
@@ -0,0 +1,145 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	shared := &Comment{ID: 1, Body: "Shared"}
+	posts := []*Post{
+		{ID: 1, Title: "First", Comments: []*Comment{shared, {ID: 2, Body: "Two"}}},
+		{ID: 2, Title: "Second", LatestComment: shared},
+	}
+
+	out := bytes.NewBuffer(nil)
+	enc := NewEncoder(out)
+	enc.Meta = &Meta{"total": 2}
+	for _, post := range posts {
+		if err := enc.Encode(post); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.Unmarshal(out.Bytes(), resp); err != nil {
+		t.Fatalf("%s: %s", err, out)
+	}
+	if len(resp.Data) != 2 || resp.Data[1].Attributes["title"] != "Second" {
+		t.Fatalf("got data %v, want both posts", resp.Data)
+	}
+	if len(resp.Included) != 2 {
+		t.Fatalf("got %d included resources, want the 2 distinct comments", len(resp.Included))
+	}
+	if (*resp.Meta)["total"] != float64(2) {
+		t.Fatalf("got meta %v, want the total", resp.Meta)
+	}
+
+	models, err := UnmarshalManyPayload(bytes.NewReader(out.Bytes()), reflect.TypeOf(new(Post)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := models[1].(*Post).LatestComment; got == nil || got.Body != "Shared" {
+		t.Fatalf("got latest comment %v, want the shared comment", got)
+	}
+}
+
+func TestEncoder_empty(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := NewEncoder(out).Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := out.String(), "{\"data\":[]}\n"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestEncoder_closed(t *testing.T) {
+	enc := NewEncoder(bytes.NewBuffer(nil))
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enc.Encode(&Post{ID: 1}); err != ErrEncoderClosed {
+		t.Fatalf("got %v, want %v", err, ErrEncoderClosed)
+	}
+}
+
+func TestEncoder_unexpectedType(t *testing.T) {
+	for _, model := range []interface{}{Post{ID: 1}, (*Post)(nil), "post", nil} {
+		if err := NewEncoder(bytes.NewBuffer(nil)).Encode(model); err != ErrUnexpectedType {
+			t.Fatalf("%#v: got %v, want %v", model, err, ErrUnexpectedType)
+		}
+	}
+}
+
+func TestMarshalStream(t *testing.T) {
+	models := make(chan interface{})
+	go func() {
+		defer close(models)
+		for i := 1; i <= 3; i++ {
+			models <- &Comment{ID: i}
+		}
+	}()
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalStream(out, models); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.Unmarshal(out.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 3 || resp.Data[2].ID != "3" || resp.Included != nil {
+		t.Fatalf("got %s, want 3 comments", out)
+	}
+}
+
+func TestMarshalSeq(t *testing.T) {
+	comments := func(yield func(interface{}) bool) {
+		for i := 1; i <= 3; i++ {
+			if !yield(&Comment{ID: i}) {
+				return
+			}
+		}
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalSeq(out, comments); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(ManyPayload)
+	if err := json.Unmarshal(out.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Data) != 3 || resp.Data[2].ID != "3" || resp.Included != nil {
+		t.Fatalf("got %s, want 3 comments", out)
+	}
+}
+
+func TestEncoder_EncodeSeqStops(t *testing.T) {
+	var yielded int
+	models := func(yield func(interface{}) bool) {
+		for _, model := range []interface{}{&Comment{ID: 1}, &BadModel{ID: 2}, &Comment{ID: 3}} {
+			yielded++
+			if !yield(model) {
+				return
+			}
+		}
+	}
+
+	if err := NewEncoder(bytes.NewBuffer(nil)).EncodeSeq(models); err == nil {
+		t.Fatal("want an error for a model with a bad tag")
+	}
+	if yielded != 2 {
+		t.Fatalf("got %d models yielded, want the sequence stopped after the error", yielded)
+	}
+}
@@ -42,10 +42,27 @@ type ErrorObject struct {
 	// Code is an application-specific error code, expressed as a string value.
 	Code string `json:"code,omitempty"`
 
+	// Source is an object containing references to the source of the error.
+	Source *ErrorSource `json:"source,omitempty"`
+
 	// Meta is an object containing non-standard meta-information about the error.
 	Meta *map[string]interface{} `json:"meta,omitempty"`
 }
 
+// ErrorSource is used to represent the `source` member of an error object.
+//
+// For more information on the JSON API spec's error source, see: http://jsonapi.org/format/#error-objects
+type ErrorSource struct {
+	// Pointer is a JSON Pointer [RFC6901] to the value in the request document that caused the error.
+	Pointer string `json:"pointer,omitempty"`
+
+	// Parameter is a string indicating which URI query parameter caused the error.
+	Parameter string `json:"parameter,omitempty"`
+
+	// Header is a string indicating the name of a single request header which caused the error.
+	Header string `json:"header,omitempty"`
+}
+
 // Error implements the `Error` interface.
 func (e *ErrorObject) Error() string {
 	return fmt.Sprintf("Error: %s %s\n", e.Title, e.Detail)
@@ -0,0 +1,228 @@
+package jsonapi
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	headerETag        = "ETag"
+	headerIfMatch     = "If-Match"
+	headerIfNoneMatch = "If-None-Match"
+)
+
+// ErrPreconditionFailed is returned by CheckIfMatch when the If-Match header
+// of a request doesn't match the current state of the resource.
+var ErrPreconditionFailed = errors.New("jsonapi: precondition failed")
+
+// ETag returns a strong entity tag for payload: the hash of its canonical
+// form, see Canonicalize, so that equal payloads have the same ETag however
+// they were built.
+func ETag(payload Payloader) (string, error) {
+	b, err := canonicalJSON(payload)
+	if err != nil {
+		return "", err
+	}
+	return etag(b), nil
+}
+
+// WithETag sets the ETag header of the response to the ETag of the written
+// payload, see ETag.
+func WithETag() WriteOption {
+	return func(c *writeConfig) {
+		c.etag = true
+	}
+}
+
+func etag(canonical []byte) string {
+	sum := sha256.Sum256(canonical)
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:]) + `"`
+}
+
+// Conditional is a middleware handling conditional requests to resource
+// endpoints with ETags, see ETag:
+//
+//   - 200 OK responses get an ETag header, unless the handler set one,
+//     computed from their canonical body
+//   - GET and HEAD requests whose If-None-Match header matches the ETag of
+//     the response are answered with 304 Not Modified
+//   - other requests with an If-Match header are answered with 412
+//     Precondition Failed, without calling the handler for them, unless it
+//     matches the ETag of the current representation of the resource, which
+//     is fetched from the handler with a GET request to the same URL
+//
+// Responses are buffered to compute their ETag. WriteResource leaves their
+// Content-Type to the writer Conditional writes them to, e.g. the negotiated
+// one of a ContentNegotiator, as long as middleware between Conditional and
+// the handler implements Unwrap, as http.ResponseController expects.
+//
+// The If-Match check is best-effort: the resource may change between the
+// GET and the handler serving the request, so two concurrent updates with
+// the same ETag can both pass it. Handlers that must prevent lost updates
+// check the precondition again with CheckIfMatch where they apply the
+// change, e.g. within the transaction updating the resource.
+func Conditional(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+		if ifMatch := r.Header.Get(headerIfMatch); ifMatch != "" && !safe {
+			current := newBufferedResponseWriter()
+			get := r.Clone(r.Context())
+			get.Method = http.MethodGet
+			get.Body = http.NoBody
+			get.ContentLength = 0
+			get.Header.Del(headerIfMatch)
+			get.Header.Del(headerContentType)
+			next.ServeHTTP(current, get)
+
+			tag, ok := current.etag()
+			if current.status != http.StatusOK || !ok || !etagMatch(ifMatch, tag, false) {
+				writePreconditionFailed(w)
+				return
+			}
+		}
+
+		buffered := newBufferedResponseWriter()
+		next.ServeHTTP(buffered, r)
+
+		if tag, ok := buffered.etag(); ok && buffered.status == http.StatusOK {
+			buffered.header.Set(headerETag, tag)
+			if safe && etagMatch(r.Header.Get(headerIfNoneMatch), tag, true) {
+				buffered.status = http.StatusNotModified
+				buffered.body.Reset()
+				buffered.header.Del(headerContentType)
+				buffered.defaultContentType = false
+			}
+		}
+
+		buffered.flushTo(w)
+	})
+}
+
+// CheckIfMatch checks the If-Match header of r, if it has one, against the
+// ETag of model, the current state of the resource as WriteResource writes
+// it, and returns ErrPreconditionFailed if it doesn't match. Unlike the check
+// of Conditional, it is atomic with the update when called with the resource
+// read in the same transaction.
+func CheckIfMatch(r *http.Request, model interface{}) error {
+	ifMatch := r.Header.Get(headerIfMatch)
+	if ifMatch == "" {
+		return nil
+	}
+
+	payload, err := Marshal(model)
+	if err != nil {
+		return err
+	}
+	tag, err := ETag(payload)
+	if err != nil {
+		return err
+	}
+	if !etagMatch(ifMatch, tag, false) {
+		return ErrPreconditionFailed
+	}
+	return nil
+}
+
+// etagMatch reports whether tag matches a list of entity tags, using the
+// weak comparison function if weak is set and the strong one otherwise.
+func etagMatch(list, tag string, weak bool) bool {
+	if list == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == strings.TrimPrefix(tag, "W/") {
+			return weak || !strings.HasPrefix(tag, "W/")
+		}
+	}
+	return false
+}
+
+func writePreconditionFailed(w http.ResponseWriter) {
+	WriteErrors(w, http.StatusPreconditionFailed, []*ErrorObject{{
+		Title:  http.StatusText(http.StatusPreconditionFailed),
+		Detail: "The resource has been modified since it was last fetched",
+		Status: "412",
+		Source: &ErrorSource{Header: headerIfMatch},
+	}})
+}
+
+// bufferedResponseWriter records a response so that it can be inspected
+// before being written.
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   *bytes.Buffer
+	// defaultContentType is set when the default Content-Type of jsonapi
+	// responses applies, see setContentType
+	defaultContentType bool
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: http.Header{}, body: bytes.NewBuffer(nil)}
+}
+
+// useDefaultContentType implements contentTypeWriter.
+func (w *bufferedResponseWriter) useDefaultContentType() {
+	w.defaultContentType = true
+}
+
+func (w *bufferedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// etag returns the ETag set by the handler, or computes it from the body.
+func (w *bufferedResponseWriter) etag() (string, bool) {
+	if tag := w.header.Get(headerETag); tag != "" {
+		return tag, true
+	}
+	if w.body.Len() == 0 {
+		return "", false
+	}
+
+	canonical, err := Canonicalize(w.body.Bytes())
+	if err != nil {
+		return "", false
+	}
+	return etag(canonical), true
+}
+
+func (w *bufferedResponseWriter) flushTo(rw http.ResponseWriter) {
+	for k, v := range w.header {
+		rw.Header()[k] = v
+	}
+	if w.defaultContentType && w.header.Get(headerContentType) == "" {
+		setContentType(rw)
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	rw.WriteHeader(w.status)
+	rw.Write(w.body.Bytes())
+}
@@ -0,0 +1,185 @@
+package jsonapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestETag(t *testing.T) {
+	a, err := Marshal(testIncludedBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(testIncludedBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	SortIncluded(b)
+
+	tagA, err := ETag(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tagB, err := ETag(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagA != tagB {
+		t.Fatalf("got %s and %s, want the same ETag regardless of included order", tagA, tagB)
+	}
+	if !strings.HasPrefix(tagA, `"`) || !strings.HasSuffix(tagA, `"`) {
+		t.Fatalf("got %s, want a strong ETag", tagA)
+	}
+
+	c, err := Marshal(&Blog{ID: 1, Title: "Other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tagC, _ := ETag(c); tagC == tagA {
+		t.Fatal("Expected different payloads to have different ETags")
+	}
+}
+
+func TestWithETag(t *testing.T) {
+	payload, err := Marshal(testIncludedBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ETag(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	if err := WriteResource(rr, http.StatusOK, testIncludedBlog(), WithETag()); err != nil {
+		t.Fatal(err)
+	}
+	if got := rr.Header().Get(headerETag); got != want {
+		t.Fatalf("got ETag %s, want %s", got, want)
+	}
+}
+
+func TestETagMatch(t *testing.T) {
+	for _, tc := range []struct {
+		list, tag  string
+		weak, want bool
+	}{
+		{`"a"`, `"a"`, false, true},
+		{`"b", "a"`, `"a"`, false, true},
+		{`*`, `"a"`, false, true},
+		{`"b"`, `"a"`, true, false},
+		{`W/"a"`, `"a"`, true, true},
+		{`W/"a"`, `"a"`, false, false},
+		{`"a"`, `W/"a"`, false, false},
+		{``, `"a"`, true, false},
+	} {
+		if got := etagMatch(tc.list, tc.tag, tc.weak); got != tc.want {
+			t.Errorf("etagMatch(%q, %q, %v) = %v, want %v", tc.list, tc.tag, tc.weak, got, tc.want)
+		}
+	}
+}
+
+func TestConditional(t *testing.T) {
+	title := "First"
+	var updates int
+	handler := Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			updates++
+			title = "Changed"
+		}
+		WriteResource(w, http.StatusOK, &Blog{ID: 1, Title: title})
+	}))
+	do := func(method, header, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/blogs/1", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := do(http.MethodGet, "", "")
+	etag := rr.Header().Get(headerETag)
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("got status %d and ETag %q, want 200 with an ETag", rr.Code, etag)
+	}
+
+	rr = do(http.MethodGet, headerIfNoneMatch, etag)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 || rr.Header().Get(headerETag) != etag {
+		t.Fatalf("got status %d, want 304 with the ETag and no body", rr.Code)
+	}
+	rr = do(http.MethodGet, headerIfNoneMatch, `"other"`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200", rr.Code)
+	}
+
+	rr = do(http.MethodPatch, headerIfMatch, `"other"`)
+	if rr.Code != http.StatusPreconditionFailed || updates != 0 {
+		t.Fatalf("got status %d after %d updates, want 412 without update", rr.Code, updates)
+	}
+	rr = do(http.MethodPatch, headerIfMatch, etag)
+	if rr.Code != http.StatusOK || updates != 1 {
+		t.Fatalf("got status %d after %d updates, want 200 with the update", rr.Code, updates)
+	}
+	if rr.Header().Get(headerETag) == etag {
+		t.Fatal("Expected the ETag of the updated resource")
+	}
+}
+
+func TestConditional_negotiatedContentType(t *testing.T) {
+	negotiator := &ContentNegotiator{Profiles: []string{"p"}}
+	handler := negotiator.Handler(Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteResource(w, http.StatusOK, &Blog{ID: 1, Title: "Title"})
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/blogs/1", nil)
+	r.Header.Set(headerAccept, MediaType+`; profile="p"`)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if got, want := rr.Header().Get(headerContentType), MediaType+`; profile=p`; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+
+	r.Header.Set(headerIfNoneMatch, rr.Header().Get(headerETag))
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+	if rr.Code != http.StatusNotModified || rr.Header().Get(headerContentType) != "" {
+		t.Fatalf("got status %d and Content-Type %q, want 304 without Content-Type", rr.Code, rr.Header().Get(headerContentType))
+	}
+
+	rr = httptest.NewRecorder()
+	Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteResource(&statusRecorder{ResponseWriter: w}, http.StatusOK, &Blog{ID: 1})
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blogs/1", nil))
+	if got := rr.Header().Get(headerContentType); got != MediaType {
+		t.Fatalf("got Content-Type %q, want %q", got, MediaType)
+	}
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	blog := &Blog{ID: 1, Title: "First"}
+	rr := httptest.NewRecorder()
+	Conditional(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteResource(w, http.StatusOK, blog)
+	})).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/blogs/1", nil))
+	etag := rr.Header().Get(headerETag)
+
+	r := httptest.NewRequest(http.MethodPatch, "/blogs/1", nil)
+	if err := CheckIfMatch(r, blog); err != nil {
+		t.Fatalf("got %v without If-Match", err)
+	}
+
+	r.Header.Set(headerIfMatch, etag)
+	if err := CheckIfMatch(r, blog); err != nil {
+		t.Fatalf("got %v, want the ETag of Conditional to match", err)
+	}
+
+	blog.Title = "Changed"
+	if err := CheckIfMatch(r, blog); err != ErrPreconditionFailed {
+		t.Fatalf("got %v, want ErrPreconditionFailed", err)
+	}
+}
@@ -35,7 +35,7 @@ func main() {
 	}
 
 	exampleHandler := &ExampleHandler{}
-	http.HandleFunc("/blogs", exampleHandler.ServeHTTP)
+	http.Handle("/blogs", jsonapi.NegotiateContent(exampleHandler))
 	exerciseHandler()
 }
 
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -16,11 +17,9 @@ const (
 // server with the jsonapi library.
 type ExampleHandler struct{}
 
+// ServeHTTP dispatches on the request method; content negotiation is left to
+// the jsonapi.NegotiateContent middleware the handler is wrapped in.
 func (h *ExampleHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get(headerAccept) != jsonapi.MediaType {
-		http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
-	}
-
 	var methodHandler http.HandlerFunc
 	switch r.Method {
 	case http.MethodPost:
@@ -47,18 +46,18 @@ func (h *ExampleHandler) createBlog(w http.ResponseWriter, r *http.Request) {
 	blog := new(Blog)
 
 	if err := jsonapiRuntime.UnmarshalPayload(r.Body, blog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		jsonapi.WriteErrors(w, http.StatusBadRequest, []*jsonapi.ErrorObject{{
+			Title:  "Invalid Request Document",
+			Detail: err.Error(),
+			Status: strconv.Itoa(http.StatusBadRequest),
+		}})
 		return
 	}
 
 	// ...do stuff with your blog...
 
-	w.WriteHeader(http.StatusCreated)
-	w.Header().Set(headerContentType, jsonapi.MediaType)
-
-	if err := jsonapiRuntime.MarshalPayload(w, blog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	jsonapiRuntime.WriteResource(w, http.StatusCreated, blog,
+		jsonapi.WithLocation(fmt.Sprintf("/blogs?id=%d", blog.ID)))
 }
 
 func (h *ExampleHandler) echoBlogs(w http.ResponseWriter, r *http.Request) {
@@ -68,11 +67,7 @@ func (h *ExampleHandler) echoBlogs(w http.ResponseWriter, r *http.Request) {
 	// but, for now
 	blogs := fixtureBlogsList()
 
-	w.WriteHeader(http.StatusOK)
-	w.Header().Set(headerContentType, jsonapi.MediaType)
-	if err := jsonapiRuntime.MarshalPayload(w, blogs); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	jsonapiRuntime.WriteResource(w, http.StatusOK, blogs)
 }
 
 func (h *ExampleHandler) showBlog(w http.ResponseWriter, r *http.Request) {
@@ -82,7 +77,11 @@ func (h *ExampleHandler) showBlog(w http.ResponseWriter, r *http.Request) {
 
 	intID, err := strconv.Atoi(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		jsonapi.WriteErrors(w, http.StatusBadRequest, []*jsonapi.ErrorObject{{
+			Title:  "Invalid ID",
+			Detail: err.Error(),
+			Status: strconv.Itoa(http.StatusBadRequest),
+		}})
 		return
 	}
 
@@ -90,12 +89,8 @@ func (h *ExampleHandler) showBlog(w http.ResponseWriter, r *http.Request) {
 
 	// but, for now
 	blog := fixtureBlogCreate(intID)
-	w.WriteHeader(http.StatusOK)
 
-	w.Header().Set(headerContentType, jsonapi.MediaType)
-	if err := jsonapiRuntime.MarshalPayload(w, blog); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	jsonapiRuntime.WriteResource(w, http.StatusOK, blog)
 }
 
 func (h *ExampleHandler) listBlogs(w http.ResponseWriter, r *http.Request) {
@@ -105,10 +100,5 @@ func (h *ExampleHandler) listBlogs(w http.ResponseWriter, r *http.Request) {
 	// but, for now
 	blogs := fixtureBlogsList()
 
-	w.Header().Set("Content-Type", jsonapi.MediaType)
-	w.WriteHeader(http.StatusOK)
-
-	if err := jsonapiRuntime.MarshalPayload(w, blogs); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	jsonapiRuntime.WriteResource(w, http.StatusOK, blogs)
 }
@@ -27,6 +27,9 @@ func TestExampleHandler_post(t *testing.T) {
 	if e, a := http.StatusCreated, rr.Code; e != a {
 		t.Fatalf("Expected a status of %d, got %d", e, a)
 	}
+	if e, a := jsonapi.MediaType, rr.Header().Get(headerContentType); e != a {
+		t.Fatalf("Expected a Content-Type of %q, got %q", e, a)
+	}
 }
 
 func TestExampleHandler_put(t *testing.T) {
@@ -85,15 +88,15 @@ func TestExampleHandler_get_list(t *testing.T) {
 	}
 }
 
-func TestHttpErrorWhenHeaderDoesNotMatch(t *testing.T) {
-	r, err := http.NewRequest(http.MethodGet, "/blogs", nil)
+func TestHttpErrorWhenContentTypeHasParameters(t *testing.T) {
+	r, err := http.NewRequest(http.MethodPost, "/blogs", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	r.Header.Set(headerAccept, "application/xml")
+	r.Header.Set(headerContentType, jsonapi.MediaType+"; charset=utf-8")
 
 	rr := httptest.NewRecorder()
-	handler := &ExampleHandler{}
+	handler := jsonapi.NegotiateContent(&ExampleHandler{})
 	handler.ServeHTTP(rr, r)
 
 	if rr.Code != http.StatusUnsupportedMediaType {
@@ -101,6 +104,22 @@ func TestHttpErrorWhenHeaderDoesNotMatch(t *testing.T) {
 	}
 }
 
+func TestHttpErrorWhenAcceptHasParameters(t *testing.T) {
+	r, err := http.NewRequest(http.MethodGet, "/blogs", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set(headerAccept, jsonapi.MediaType+"; charset=utf-8")
+
+	rr := httptest.NewRecorder()
+	handler := jsonapi.NegotiateContent(&ExampleHandler{})
+	handler.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Fatal("expected Not Acceptable status error")
+	}
+}
+
 func TestHttpErrorWhenMethodDoesNotMatch(t *testing.T) {
 	r, err := http.NewRequest(http.MethodPatch, "/blogs", nil)
 	if err != nil {
@@ -1 +1,3 @@
-module github.com/google/jsonapi
\ No newline at end of file
+module github.com/google/jsonapi
+
+go 1.23
@@ -0,0 +1,228 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+)
+
+// ErrUnexpectedPayload is returned by NewDocument when given a payload other
+// than a *OnePayload or *ManyPayload.
+var ErrUnexpectedPayload = errors.New("jsonapi: payload should be a *OnePayload or *ManyPayload")
+
+// ErrNoPrimaryData is returned by ReadDocument when the document has no
+// "data" member, as error documents.
+var ErrNoPrimaryData = errors.New("jsonapi: document has no primary data")
+
+// Document navigates the resources of a compound document, such as the
+// payload returned by Marshal or a decoded OnePayload or ManyPayload,
+// without type switching on its relationships.
+//
+// Data, Included and Resources read the payload on each call, so changes
+// made to the payload are visible through them. Find and Related look the
+// resources up in an index built when the Document is created: resources
+// added to the payload later are only found by a new Document.
+//
+// ToOne and ToMany modify the payload, see their documentation.
+type Document struct {
+	payload Payloader
+	index   map[string]*Node
+}
+
+// NewDocument returns the Document of payload, a *OnePayload or
+// *ManyPayload.
+func NewDocument(payload Payloader) (*Document, error) {
+	switch payload.(type) {
+	case *OnePayload, *ManyPayload:
+		return newDocument(payload), nil
+	}
+	return nil, ErrUnexpectedPayload
+}
+
+// newDocument returns the Document of payload with its resources indexed by
+// identity, keeping the first of resources with the same identity.
+func newDocument(payload Payloader) *Document {
+	d := &Document{payload: payload}
+	resources := d.Resources()
+	d.index = make(map[string]*Node, len(resources))
+	for _, n := range resources {
+		if n == nil {
+			continue
+		}
+		if _, exists := d.index[nodeKey(n)]; !exists {
+			d.index[nodeKey(n)] = n
+		}
+	}
+	return d
+}
+
+// ReadDocument decodes the document read from in into a *OnePayload, or a
+// *ManyPayload if its primary data is an array, and returns its Document. It
+// returns ErrNoPrimaryData if the document has no "data" member.
+func ReadDocument(in io.Reader) (*Document, error) {
+	b, err := ioutil.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+
+	var document struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(b, &document); err != nil {
+		return nil, err
+	}
+	if document.Data == nil {
+		return nil, ErrNoPrimaryData
+	}
+
+	var payload Payloader = new(OnePayload)
+	if isJSONArray(document.Data) {
+		payload = new(ManyPayload)
+	}
+	if err := json.Unmarshal(b, payload); err != nil {
+		return nil, err
+	}
+	return newDocument(payload), nil
+}
+
+// isJSONArray reports whether the JSON value data is an array.
+func isJSONArray(data json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+}
+
+// Payload returns the payload of the document.
+func (d *Document) Payload() Payloader {
+	return d.payload
+}
+
+// Data returns the primary data of the document.
+func (d *Document) Data() []*Node {
+	switch p := d.payload.(type) {
+	case *OnePayload:
+		if p.Data != nil {
+			return []*Node{p.Data}
+		}
+	case *ManyPayload:
+		return p.Data
+	}
+	return nil
+}
+
+// Included returns the included resources of the document.
+func (d *Document) Included() []*Node {
+	switch p := d.payload.(type) {
+	case *OnePayload:
+		return p.Included
+	case *ManyPayload:
+		return p.Included
+	}
+	return nil
+}
+
+// Resources returns the resources of the document: its primary data, then
+// its included resources.
+func (d *Document) Resources() []*Node {
+	return append(append([]*Node{}, d.Data()...), d.Included()...)
+}
+
+// Find returns the resource of the document of the given type and id, or
+// nil if there is none.
+func (d *Document) Find(resourceType, id string) *Node {
+	return d.index[nodeKey(&Node{Type: resourceType, ID: id})]
+}
+
+// Related returns the resources identified by the linkage of the
+// relationship name of n, a to-one or to-many relationship. Each is the
+// resource of the document with the same identity, or the resource
+// identifier object itself if the document doesn't have it, as when
+// unmarshalling. It returns nil if the relationship is absent or empty.
+func (d *Document) Related(n *Node, name string) []*Node {
+	identifiers := linkageNodes(n.Relationships[name])
+	if len(identifiers) == 0 {
+		return nil
+	}
+
+	related := make([]*Node, len(identifiers))
+	for i, identifier := range identifiers {
+		related[i] = identifier
+		if full := d.index[nodeKey(identifier)]; full != nil {
+			related[i] = full
+		}
+	}
+	return related
+}
+
+// ToOne returns the to-one relationship name of n, and whether n has it.
+//
+// ToOne modifies n: a relationship decoded from JSON, a
+// map[string]interface{}, is converted to a *RelationshipOneNode and
+// n.Relationships[name] is set to it, so that changes to it are part of the
+// payload. It is left as it is if it has no data or has extension members,
+// and the *RelationshipOneNode returned is then a copy.
+func (d *Document) ToOne(n *Node, name string) (*RelationshipOneNode, bool) {
+	switch rel := n.Relationships[name].(type) {
+	case *RelationshipOneNode:
+		return rel, true
+	case map[string]interface{}:
+		if data, hasData := rel[memberData]; hasData && data != nil {
+			if _, isObject := data.(map[string]interface{}); !isObject {
+				return nil, false
+			}
+		}
+		relationship := new(RelationshipOneNode)
+		if !decodeRelationship(n, name, rel, relationship) {
+			return nil, false
+		}
+		return relationship, true
+	}
+	return nil, false
+}
+
+// ToMany returns the to-many relationship name of n, and whether n has it.
+//
+// ToMany modifies n: a relationship decoded from JSON, a
+// map[string]interface{}, is converted to a *RelationshipManyNode and
+// n.Relationships[name] is set to it, so that changes to it are part of the
+// payload. It is left as it is if it has no data or has extension members,
+// and the *RelationshipManyNode returned is then a copy.
+func (d *Document) ToMany(n *Node, name string) (*RelationshipManyNode, bool) {
+	switch rel := n.Relationships[name].(type) {
+	case *RelationshipManyNode:
+		return rel, true
+	case map[string]interface{}:
+		if data, hasData := rel[memberData]; hasData {
+			if _, isArray := data.([]interface{}); !isArray {
+				return nil, false
+			}
+		}
+		relationship := new(RelationshipManyNode)
+		if !decodeRelationship(n, name, rel, relationship) {
+			return nil, false
+		}
+		return relationship, true
+	}
+	return nil, false
+}
+
+// decodeRelationship decodes the relationship name of n, a decoded JSON
+// object, into relationship, and replaces it with relationship in n if no
+// member is lost.
+func decodeRelationship(n *Node, name string, rel map[string]interface{}, relationship interface{}) bool {
+	b, err := json.Marshal(rel)
+	if err != nil || json.Unmarshal(b, relationship) != nil {
+		return false
+	}
+
+	if _, hasData := rel[memberData]; !hasData {
+		return true
+	}
+	for member := range rel {
+		if member != memberData && member != "links" && member != "meta" {
+			return true
+		}
+	}
+	n.Relationships[name] = relationship
+	return true
+}
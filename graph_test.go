@@ -0,0 +1,220 @@
+package jsonapi
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testDocument(t *testing.T) *Document {
+	t.Helper()
+	payload, err := Marshal(testIncludedBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := NewDocument(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func readTestDocument(t *testing.T) *Document {
+	t.Helper()
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, testIncludedBlog()); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := ReadDocument(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestDocument_resources(t *testing.T) {
+	for name, doc := range map[string]*Document{"marshalled": testDocument(t), "decoded": readTestDocument(t)} {
+		if got := includedKeys(doc.Data()); strings.Join(got, " ") != "blogs,1" {
+			t.Errorf("%s: got data %v", name, got)
+		}
+		want := "blogs,1 comments,9 comments,4 comments,1 posts,3 posts,2 comments,8 posts,5"
+		if got := includedKeys(doc.Resources()); strings.Join(got, " ") != want {
+			t.Errorf("%s: got resources %v, want %s", name, got, want)
+		}
+		if len(doc.Included()) != 7 {
+			t.Errorf("%s: got %d included resources", name, len(doc.Included()))
+		}
+
+		if n := doc.Find("posts", "2"); n == nil || n.Attributes["title"] != "Two" {
+			t.Errorf("%s: got post %+v", name, n)
+		}
+		if n := doc.Find("posts", "4"); n != nil {
+			t.Errorf("%s: got %+v, want no post 4", name, n)
+		}
+	}
+}
+
+func TestDocument_index(t *testing.T) {
+	first, duplicate := &Node{Type: "posts", ID: "1"}, &Node{Type: "posts", ID: "1"}
+	payload := &ManyPayload{Data: []*Node{first, nil}, Included: []*Node{duplicate}}
+	doc, err := NewDocument(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := doc.Find("posts", "1"); n != first {
+		t.Fatalf("got %+v, want the first post", n)
+	}
+
+	payload.Included = append(payload.Included, &Node{Type: "posts", ID: "2"})
+	if len(doc.Resources()) != 4 {
+		t.Fatalf("got %d resources, want the added post", len(doc.Resources()))
+	}
+	if n := doc.Find("posts", "2"); n != nil {
+		t.Fatalf("got %+v, want resources added after NewDocument not to be indexed", n)
+	}
+}
+
+func TestDocument_related(t *testing.T) {
+	for name, doc := range map[string]*Document{"marshalled": testDocument(t), "decoded": readTestDocument(t)} {
+		blog := doc.Find("blogs", "1")
+
+		posts := doc.Related(blog, "posts")
+		if got := includedKeys(posts); strings.Join(got, " ") != "posts,3 posts,2" {
+			t.Errorf("%s: got posts %v", name, got)
+		}
+		if len(posts) > 0 && posts[0].Attributes["title"] != "Three" {
+			t.Errorf("%s: got %+v, want the included post", name, posts[0])
+		}
+
+		current := doc.Related(blog, "current_post")
+		if len(current) != 1 || current[0] != doc.Find("posts", "5") {
+			t.Errorf("%s: got current post %v", name, current)
+		}
+		if comments := doc.Related(current[0], "latest_comment"); len(comments) != 1 || comments[0] != doc.Find("comments", "8") {
+			t.Errorf("%s: got latest comment %v", name, comments)
+		}
+
+		if related := doc.Related(blog, "missing"); related != nil {
+			t.Errorf("%s: got %v for a missing relationship", name, related)
+		}
+	}
+}
+
+func TestDocument_relatedIdentifier(t *testing.T) {
+	doc, err := ReadDocument(strings.NewReader(`{"data":{"type":"posts","id":"1","relationships":{` +
+		`"author":{"data":{"type":"people","id":"9"}},"editor":{"data":null}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := doc.Data()[0]
+
+	author := doc.Related(post, "author")
+	if len(author) != 1 || nodeKey(author[0]) != "people,9" {
+		t.Fatalf("got %v, want the resource identifier", author)
+	}
+	if editor := doc.Related(post, "editor"); editor != nil {
+		t.Fatalf("got %v for a null relationship", editor)
+	}
+}
+
+func TestDocument_toOne(t *testing.T) {
+	doc := testDocument(t)
+	blog := doc.Data()[0]
+
+	current, ok := doc.ToOne(blog, "current_post")
+	if !ok || current.Data.ID != "5" {
+		t.Fatalf("got %+v", current)
+	}
+	if _, ok := doc.ToOne(blog, "posts"); ok {
+		t.Fatal("posts is not a to-one relationship")
+	}
+	if _, ok := doc.ToOne(blog, "missing"); ok {
+		t.Fatal("want no missing relationship")
+	}
+
+	decoded := readTestDocument(t)
+	blog = decoded.Data()[0]
+	current, ok = decoded.ToOne(blog, "current_post")
+	if !ok || current.Data.ID != "5" || current.Meta == nil {
+		t.Fatalf("got %+v", current)
+	}
+	if blog.Relationships["current_post"] != current {
+		t.Fatal("want the decoded relationship to be replaced by its typed value")
+	}
+	if _, ok := decoded.ToOne(blog, "posts"); ok {
+		t.Fatal("posts is not a to-one relationship")
+	}
+}
+
+func TestDocument_toMany(t *testing.T) {
+	for name, doc := range map[string]*Document{"marshalled": testDocument(t), "decoded": readTestDocument(t)} {
+		blog := doc.Data()[0]
+
+		posts, ok := doc.ToMany(blog, "posts")
+		if !ok || len(posts.Data) != 2 || posts.Data[1].ID != "2" {
+			t.Errorf("%s: got %+v", name, posts)
+			continue
+		}
+		if _, ok := doc.ToMany(blog, "current_post"); ok {
+			t.Errorf("%s: current_post is not a to-many relationship", name)
+		}
+
+		// Changes to the relationship are part of the payload
+		posts.Data = posts.Data[:1]
+		if related := doc.Related(blog, "posts"); len(related) != 1 {
+			t.Errorf("%s: got %v after removing a post", name, related)
+		}
+	}
+}
+
+func TestDocument_decodedRelationshipsKeepMembers(t *testing.T) {
+	doc, err := ReadDocument(strings.NewReader(`{"data":{"type":"posts","id":"1","relationships":{` +
+		`"tags":{"data":[],"ext:order":"name"},"author":{"links":{"related":"/posts/1/author"}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	post := doc.Data()[0]
+
+	if tags, ok := doc.ToMany(post, "tags"); !ok || len(tags.Data) != 0 {
+		t.Fatalf("got %+v", tags)
+	}
+	if _, isMap := post.Relationships["tags"].(map[string]interface{}); !isMap {
+		t.Fatal("want a relationship with extension members to be kept")
+	}
+
+	author, ok := doc.ToOne(post, "author")
+	if !ok || author.Data != nil || author.Links == nil {
+		t.Fatalf("got %+v", author)
+	}
+	if _, isMap := post.Relationships["author"].(map[string]interface{}); !isMap {
+		t.Fatal("want a relationship without data to be kept")
+	}
+}
+
+func TestNewDocument_unexpectedPayload(t *testing.T) {
+	if _, err := NewDocument(new(RelationshipOneNode)); err != ErrUnexpectedPayload {
+		t.Fatalf("got error %v, want ErrUnexpectedPayload", err)
+	}
+}
+
+func TestReadDocument(t *testing.T) {
+	doc, err := ReadDocument(strings.NewReader(`{"data":[{"type":"posts","id":"1"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, isMany := doc.Payload().(*ManyPayload); !isMany || len(doc.Data()) != 1 {
+		t.Fatalf("got %+v", doc.Payload())
+	}
+
+	doc, err = ReadDocument(strings.NewReader(`{"data":null}`))
+	if err != nil || doc.Data() != nil {
+		t.Fatalf("got %v and error %v", doc, err)
+	}
+
+	if _, err := ReadDocument(strings.NewReader(`{"errors":[]}`)); err != ErrNoPrimaryData {
+		t.Fatalf("got error %v, want ErrNoPrimaryData", err)
+	}
+	if _, err := ReadDocument(strings.NewReader(`{"data":`)); err == nil {
+		t.Fatal("want an error for invalid JSON")
+	}
+}
@@ -0,0 +1,233 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ErrIncludedConflict is returned by Marshal with the RejectConflicts policy
+// when occurrences of the same related record have contradictory values.
+var ErrIncludedConflict = errors.New("jsonapi: contradictory values for an included resource")
+
+// ConflictPolicy decides which value is kept when occurrences of the same
+// related record, which are merged into a single included resource, have
+// different values for the same attribute, relationship, links or meta key.
+type ConflictPolicy int
+
+const (
+	// KeepFirst keeps the value of the first occurrence
+	KeepFirst ConflictPolicy = iota
+	// KeepLast keeps the value of the last occurrence
+	KeepLast
+	// RejectConflicts fails with ErrIncludedConflict
+	RejectConflicts
+)
+
+// MarshalOption configures MarshalWithOptions.
+type MarshalOption func(*marshalConfig)
+
+type marshalConfig struct {
+	conflicts ConflictPolicy
+}
+
+// WithConflictPolicy sets the policy applied when occurrences of the same
+// related record disagree; the default is KeepFirst.
+func WithConflictPolicy(policy ConflictPolicy) MarshalOption {
+	return func(c *marshalConfig) {
+		c.conflicts = policy
+	}
+}
+
+func (c *marshalConfig) included() *includedNodes {
+	in := newIncludedNodes()
+	in.conflicts = c.conflicts
+	return in
+}
+
+// includedNodes collects the related resources of a compound document,
+// deduplicated by type and id, in the order the walk of the models completes
+// them, so that documents marshalled from the same models are
+// byte-identical: depth first, following the order of the struct fields,
+// with each resource after the resources it relates to.
+//
+// A record reachable through several relationships is visited once per
+// path, and each occurrence may have different relationships populated, so
+// occurrences are merged: the included resource has the union of their
+// attributes, relationships, links and meta.
+type includedNodes struct {
+	nodes     []*Node
+	keys      map[string]*Node
+	conflicts ConflictPolicy
+}
+
+func newIncludedNodes() *includedNodes {
+	return &includedNodes{nodes: []*Node{}, keys: map[string]*Node{}}
+}
+
+func (in *includedNodes) append(nodes ...*Node) error {
+	for _, n := range nodes {
+		k := nodeKey(n)
+
+		if existing, hasNode := in.keys[k]; hasNode {
+			if err := in.merge(existing, n); err != nil {
+				return err
+			}
+			continue
+		}
+
+		in.keys[k] = n
+		in.nodes = append(in.nodes, n)
+	}
+
+	return nil
+}
+
+func (in *includedNodes) values() []*Node {
+	return in.nodes
+}
+
+// merge merges the members of src, another occurrence of the same resource,
+// into dst.
+func (in *includedNodes) merge(dst, src *Node) error {
+	if len(src.Attributes) > 0 && dst.Attributes == nil {
+		dst.Attributes = map[string]interface{}{}
+	}
+	for k, v := range src.Attributes {
+		current, exists := dst.Attributes[k]
+		if !exists || reflect.DeepEqual(current, v) {
+			dst.Attributes[k] = v
+			continue
+		}
+		if err := in.resolve(dst, "attribute "+k, func() { dst.Attributes[k] = v }); err != nil {
+			return err
+		}
+	}
+
+	if len(src.Relationships) > 0 && dst.Relationships == nil {
+		dst.Relationships = map[string]interface{}{}
+	}
+	for k, v := range src.Relationships {
+		current, exists := dst.Relationships[k]
+		if !exists || !hasRelationshipData(current) || reflect.DeepEqual(current, v) {
+			dst.Relationships[k] = v
+			continue
+		}
+		if !hasRelationshipData(v) {
+			continue
+		}
+		if err := in.resolve(dst, "relationship "+k, func() { dst.Relationships[k] = v }); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case src.Links == nil || reflect.DeepEqual(dst.Links, src.Links):
+	case dst.Links == nil:
+		dst.Links = src.Links
+	default:
+		if err := in.resolve(dst, "links", func() { dst.Links = src.Links }); err != nil {
+			return err
+		}
+	}
+
+	if src.Meta != nil {
+		// The meta of dst may be the model's own, so it is copied
+		meta := Meta{}
+		if dst.Meta != nil {
+			for k, v := range *dst.Meta {
+				meta[k] = v
+			}
+		}
+		dst.Meta = &meta
+
+		for k, v := range *src.Meta {
+			current, exists := meta[k]
+			if !exists || reflect.DeepEqual(current, v) {
+				meta[k] = v
+				continue
+			}
+			if err := in.resolve(dst, "meta "+k, func() { meta[k] = v }); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolve applies the conflict policy to a member with contradictory
+// values; keepLast replaces the value of dst.
+func (in *includedNodes) resolve(dst *Node, member string, keepLast func()) error {
+	switch in.conflicts {
+	case KeepLast:
+		keepLast()
+	case RejectConflicts:
+		return fmt.Errorf("%w: %s of %s", ErrIncludedConflict, member, nodeKey(dst))
+	}
+	return nil
+}
+
+// hasRelationshipData reports whether a relationship built by visitModelNode
+// has resource linkage.
+func hasRelationshipData(relationship interface{}) bool {
+	switch rel := relationship.(type) {
+	case *RelationshipOneNode:
+		return rel.Data != nil
+	case *RelationshipManyNode:
+		return len(rel.Data) > 0
+	}
+	return relationship != nil
+}
+
+// SortIncluded sorts the included resources of payload by type, then id, and
+// local identifier for resources without an id. Integer ids come first, in
+// numerical order so that "9" comes before "10", then the other ids in
+// string order.
+//
+// Marshal emits the included resources in the order the walk of the models
+// completes them, each after the resources it relates to, e.g. the comments
+// of a post before the post; this order is stable but follows the models'
+// relationships, and sorting makes it independent of them, e.g. for golden
+// files.
+func SortIncluded(payload Payloader) {
+	var included []*Node
+	switch p := payload.(type) {
+	case *OnePayload:
+		included = p.Included
+	case *ManyPayload:
+		included = p.Included
+	}
+
+	sort.SliceStable(included, func(i, j int) bool {
+		a, b := included[i], included[j]
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.ID != b.ID {
+			return lessID(a.ID, b.ID)
+		}
+		return a.LID < b.LID
+	})
+}
+
+// lessID reports whether id a sorts before id b. Integer ids come first,
+// in numerical order, then the other ids in string order, so that the order
+// is total whatever the mix of ids.
+func lessID(a, b string) bool {
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		if x != y {
+			return x < y
+		}
+	case errA == nil:
+		return true
+	case errB == nil:
+		return false
+	}
+	return a < b
+}
@@ -0,0 +1,199 @@
+package jsonapi
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Marshal keeps its signature; options go through MarshalWithOptions
+var _ func(interface{}) (Payloader, error) = Marshal
+
+func testIncludedBlog() *Blog {
+	return &Blog{
+		ID:    1,
+		Title: "Blog",
+		Posts: []*Post{
+			{ID: 3, Title: "Three", Comments: []*Comment{{ID: 9}, {ID: 4}}},
+			{ID: 2, Title: "Two", Comments: []*Comment{{ID: 4}, {ID: 1}}},
+		},
+		CurrentPost: &Post{ID: 5, LatestComment: &Comment{ID: 8}},
+	}
+}
+
+func includedKeys(included []*Node) []string {
+	keys := make([]string, len(included))
+	for i, n := range included {
+		keys[i] = nodeKey(n)
+	}
+	return keys
+}
+
+func TestMarshalPayload_includedIsDeterministic(t *testing.T) {
+	want := bytes.NewBuffer(nil)
+	if err := MarshalPayload(want, testIncludedBlog()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 50; i++ {
+		got := bytes.NewBuffer(nil)
+		if err := MarshalPayload(got, testIncludedBlog()); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Bytes(), want.Bytes()) {
+			t.Fatalf("run %d: got\n%s\nwant\n%s", i, got, want)
+		}
+	}
+}
+
+func TestMarshal_includedInWalkOrder(t *testing.T) {
+	payload, err := Marshal(testIncludedBlog())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := includedKeys(payload.(*OnePayload).Included)
+	want := []string{
+		"comments,9", "comments,4", "comments,1",
+		"posts,3", "posts,2",
+		"comments,8", "posts,5",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSortIncluded(t *testing.T) {
+	payload, err := Marshal([]*Blog{testIncludedBlog()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	SortIncluded(payload)
+
+	got := includedKeys(payload.(*ManyPayload).Included)
+	want := []string{
+		"comments,1", "comments,4", "comments,8", "comments,9",
+		"posts,2", "posts,3", "posts,5",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestSortIncluded_numericIDs(t *testing.T) {
+	payload := &OnePayload{Included: []*Node{
+		{Type: "posts", ID: "10"}, {Type: "posts", ID: "b"}, {Type: "posts", ID: "9"},
+		{Type: "posts", ID: "a"}, {Type: "comments", ID: "100"}, {Type: "posts", ID: "-1"},
+	}}
+	SortIncluded(payload)
+
+	got := strings.Join(includedKeys(payload.Included), " ")
+	if want := "comments,100 posts,-1 posts,9 posts,10 posts,a posts,b"; got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestSortIncluded_mixedIDs(t *testing.T) {
+	ids := []string{"9", "10", "1a", "a", "-1", "01", "1", "99999999999999999999"}
+	want := "posts,-1 posts,01 posts,1 posts,9 posts,10 posts,1a posts,99999999999999999999 posts,a"
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		payload := &OnePayload{}
+		for _, j := range r.Perm(len(ids)) {
+			payload.Included = append(payload.Included, &Node{Type: "posts", ID: ids[j]})
+		}
+		SortIncluded(payload)
+
+		if got := strings.Join(includedKeys(payload.Included), " "); got != want {
+			t.Fatalf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func testConflictingBlog(currentTitle string) *Blog {
+	return &Blog{
+		ID: 1,
+		Posts: []*Post{
+			{ID: 1, Title: "One", Comments: []*Comment{{ID: 2}}},
+		},
+		CurrentPost: &Post{ID: 1, Title: currentTitle, LatestComment: &Comment{ID: 3}},
+	}
+}
+
+func includedNode(t *testing.T, payload Payloader, key string) *Node {
+	for _, n := range payload.(*OnePayload).Included {
+		if nodeKey(n) == key {
+			return n
+		}
+	}
+	t.Fatalf("%s is not included", key)
+	return nil
+}
+
+func TestMarshal_mergesIncludedOccurrences(t *testing.T) {
+	payload, err := MarshalWithOptions(testConflictingBlog("One"), WithConflictPolicy(RejectConflicts))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := includedNode(t, payload, "posts,1")
+	comments := post.Relationships["comments"].(*RelationshipManyNode)
+	if len(comments.Data) != 1 || comments.Data[0].ID != "2" {
+		t.Fatalf("got comments %v, want the comments of the first occurrence", comments.Data)
+	}
+	latest := post.Relationships["latest_comment"].(*RelationshipOneNode)
+	if latest.Data == nil || latest.Data.ID != "3" {
+		t.Fatalf("got latest comment %v, want the one of the second occurrence", latest.Data)
+	}
+	if len(payload.(*OnePayload).Included) != 3 {
+		t.Fatalf("got %v, want the post and its 2 comments", includedKeys(payload.(*OnePayload).Included))
+	}
+}
+
+func TestMarshal_includedConflictPolicies(t *testing.T) {
+	for policy, want := range map[ConflictPolicy]string{KeepFirst: "One", KeepLast: "Other"} {
+		payload, err := MarshalWithOptions(testConflictingBlog("Other"), WithConflictPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := includedNode(t, payload, "posts,1").Attributes["title"]; got != want {
+			t.Errorf("policy %d: got title %v, want %s", policy, got, want)
+		}
+	}
+
+	_, err := MarshalWithOptions(testConflictingBlog("Other"), WithConflictPolicy(RejectConflicts))
+	if !errors.Is(err, ErrIncludedConflict) {
+		t.Fatalf("got %v, want %v", err, ErrIncludedConflict)
+	}
+	if !strings.Contains(err.Error(), "attribute title of posts,1") {
+		t.Fatalf("got %q, want the conflicting member", err)
+	}
+}
+
+func TestMarshal_includedMergeKeepsModelMeta(t *testing.T) {
+	meta := &Meta{"a": 1}
+	in := newIncludedNodes()
+	if err := in.append(&Node{Type: "posts", ID: "1", Meta: meta}); err != nil {
+		t.Fatal(err)
+	}
+	if err := in.append(&Node{Type: "posts", ID: "1", Meta: &Meta{"b": 2}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*in.values()[0].Meta) != 2 || len(*meta) != 1 {
+		t.Fatalf("got meta %v and %v, want merged meta without changing the model's", *in.values()[0].Meta, *meta)
+	}
+}
@@ -0,0 +1,20 @@
+// Command jsonapivet reports malformed jsonapi struct tags.
+//
+// Usage:
+//
+//	go install github.com/google/jsonapi/jsonapivet/cmd/jsonapivet
+//	jsonapivet ./...
+//
+// It can also be run by go vet:
+//
+//	go vet -vettool=$(which jsonapivet) ./...
+package main
+
+import (
+	"github.com/google/jsonapi/jsonapivet"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(jsonapivet.Analyzer)
+}
@@ -0,0 +1,208 @@
+// Package jsonapivet defines an Analyzer that reports malformed jsonapi
+// struct tags, which the jsonapi package otherwise only detects at run time,
+// e.g. as jsonapi.ErrBadJSONAPIStructTag.
+//
+// It checks that:
+//
+//   - tags have an annotation the jsonapi package knows, with the expected
+//     number of arguments
+//   - "primary" fields are strings or integers
+//   - "relation" fields are struct pointers or slices of struct pointers
+//   - "client-id" and "lid" fields are strings
+//   - options are supported by their annotation, e.g. no misspelled
+//     "omitempty" or "iso8601"
+//
+// The cmd/jsonapivet command runs it as a standalone vet tool. Both live in
+// the github.com/google/jsonapi/jsonapivet module, so that the jsonapi
+// module doesn't depend on golang.org/x/tools.
+package jsonapivet
+
+import (
+	"go/ast"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// The struct tag annotations and options of the jsonapi package
+const (
+	annotationJSONAPI   = "jsonapi"
+	annotationPrimary   = "primary"
+	annotationClientID  = "client-id"
+	annotationLID       = "lid"
+	annotationAttribute = "attr"
+	annotationRelation  = "relation"
+	annotationOmitEmpty = "omitempty"
+	annotationISO8601   = "iso8601"
+	annotationRFC3339   = "rfc3339"
+	annotationSeperator = ","
+)
+
+// Analyzer reports malformed jsonapi struct tags.
+var Analyzer = &analysis.Analyzer{
+	Name:     "jsonapivet",
+	Doc:      "report malformed jsonapi struct tags",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// options lists the options supported by each annotation.
+var options = map[string][]string{
+	annotationPrimary:   {},
+	annotationClientID:  {},
+	annotationLID:       {},
+	annotationAttribute: {annotationOmitEmpty, annotationISO8601, annotationRFC3339},
+	annotationRelation:  {annotationOmitEmpty},
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	in := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	in.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		for _, field := range n.(*ast.StructType).Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			value, ok := reflect.StructTag(tag).Lookup(annotationJSONAPI)
+			if !ok {
+				continue
+			}
+			checkField(pass, field, value)
+		}
+	})
+
+	return nil, nil
+}
+
+func checkField(pass *analysis.Pass, field *ast.Field, value string) {
+	args := strings.Split(value, annotationSeperator)
+	annotation := args[0]
+
+	supported, known := options[annotation]
+	switch {
+	case !known:
+		pass.Reportf(field.Tag.Pos(), "unknown jsonapi annotation %q", annotation)
+		return
+	case (annotation == annotationClientID || annotation == annotationLID) && len(args) != 1:
+		pass.Reportf(field.Tag.Pos(), "bad jsonapi struct tag %q: %q takes no arguments", value, annotation)
+		return
+	case annotation != annotationClientID && annotation != annotationLID && (len(args) < 2 || args[1] == ""):
+		pass.Reportf(field.Tag.Pos(), "bad jsonapi struct tag %q: %q needs a name", value, annotation)
+		return
+	}
+
+	if len(args) > 2 {
+		for _, o := range args[2:] {
+			if !contains(supported, o) {
+				pass.Reportf(field.Tag.Pos(), "unknown %q option %q%s", annotation, o, suggestion(o, supported))
+			}
+		}
+	}
+
+	t := pass.TypesInfo.TypeOf(field.Type)
+	if t == nil {
+		return
+	}
+	switch annotation {
+	case annotationPrimary:
+		if !isPrimaryType(t) {
+			pass.Reportf(field.Type.Pos(), "jsonapi primary field of type %s is not a string or an integer", t)
+		}
+	case annotationClientID, annotationLID:
+		if !isKind(t, types.IsString) {
+			pass.Reportf(field.Type.Pos(), "jsonapi %q field of type %s is not a string", annotation, t)
+		}
+	case annotationRelation:
+		if !isRelationType(t) {
+			pass.Reportf(field.Type.Pos(), "jsonapi relation field of type %s is not a struct pointer or a slice of struct pointers", t)
+		}
+	}
+}
+
+// isPrimaryType reports whether t, or the type it points to, is a string or
+// an integer.
+func isPrimaryType(t types.Type) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return isKind(t, types.IsString|types.IsInteger)
+}
+
+func isKind(t types.Type, info types.BasicInfo) bool {
+	basic, ok := t.Underlying().(*types.Basic)
+	return ok && basic.Info()&info != 0
+}
+
+// isRelationType reports whether t is *Struct or []*Struct.
+func isRelationType(t types.Type) bool {
+	if s, ok := t.Underlying().(*types.Slice); ok {
+		t = s.Elem()
+	}
+	p, ok := t.Underlying().(*types.Pointer)
+	if !ok {
+		return false
+	}
+	_, ok = p.Elem().Underlying().(*types.Struct)
+	return ok
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestion returns a hint naming the supported option closest to the
+// unknown option o, if it looks like a misspelling of it.
+func suggestion(o string, supported []string) string {
+	for _, s := range supported {
+		if distance(strings.ToLower(strings.TrimSpace(o)), s) <= 2 {
+			return ", did you mean " + strconv.Quote(s) + "?"
+		}
+	}
+	return ""
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(b); j++ {
+			current := row[j]
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			row[j] = min3(row[j]+1, row[j-1]+1, prev+cost)
+			prev = current
+		}
+	}
+	return row[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
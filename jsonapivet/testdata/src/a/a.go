@@ -0,0 +1,33 @@
+package a
+
+import "time"
+
+type ID string
+
+type Valid struct {
+	ID        ID         `jsonapi:"primary,valids"`
+	LID       string     `jsonapi:"lid"`
+	ClientID  string     `jsonapi:"client-id"`
+	Title     string     `jsonapi:"attr,title,omitempty" json:"title"`
+	CreatedAt *time.Time `jsonapi:"attr,created_at,iso8601"`
+	Author    *Valid     `jsonapi:"relation,author,omitempty"`
+	Related   []*Valid   `jsonapi:"relation,related"`
+	Untagged  float64    `json:"untagged"`
+}
+
+type BadModel struct {
+	ID int `jsonapi:"primary"` // want `bad jsonapi struct tag "primary": "primary" needs a name`
+}
+
+type Invalid struct {
+	ID      float64 `jsonapi:"primary,invalids"`         // want `jsonapi primary field of type float64 is not a string or an integer`
+	Title   string  `jsonapi:"attr"`                     // want `bad jsonapi struct tag "attr": "attr" needs a name`
+	Body    string  `jsonapi:"attr,body,omitmepty"`      // want `unknown "attr" option "omitmepty", did you mean "omitempty"\?`
+	Date    string  `jsonapi:"attr,date,iso8061"`        // want `unknown "attr" option "iso8061", did you mean "iso8601"\?`
+	Extra   string  `jsonapi:"attr,extra,upper"`         // want `unknown "attr" option "upper"`
+	Kind    string  `jsonapi:"atr,kind"`                 // want `unknown jsonapi annotation "atr"`
+	LID     int     `jsonapi:"lid"`                      // want `jsonapi "lid" field of type int is not a string`
+	Client  string  `jsonapi:"client-id,uuid"`           // want `bad jsonapi struct tag "client-id,uuid": "client-id" takes no arguments`
+	Author  Valid   `jsonapi:"relation,author"`          // want `jsonapi relation field of type a.Valid is not a struct pointer or a slice of struct pointers`
+	Authors []Valid `jsonapi:"relation,authors,iso8601"` // want `unknown "relation" option "iso8601"` `jsonapi relation field of type \[\]a.Valid is not a struct pointer or a slice of struct pointers`
+}
@@ -0,0 +1,223 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrFullLinkage is returned when writing a compound document that violates
+// full linkage with the WithLinkageValidation option.
+var ErrFullLinkage = errors.New("jsonapi: compound document violates full linkage")
+
+const linkageErrorTitle = "Full Linkage Violation"
+
+// ValidateLinkage checks that payload, a *OnePayload or *ManyPayload, is a
+// valid compound document:
+//
+//   - every included resource is reachable from the primary data through
+//     resource linkage (full linkage)
+//   - no resource appears more than once in the primary data, in included,
+//     or in both
+//   - no to-many relationship lists the same resource twice
+//   - no resource of the primary data or included is null
+//
+// It returns an error object per violation, whose source points at the
+// offending resource or linkage, e.g. "/included/2"; nil means the document
+// is valid.
+//
+// http://jsonapi.org/format/#document-compound-documents
+func ValidateLinkage(payload Payloader) []*ErrorObject {
+	var data, included []*Node
+	switch p := payload.(type) {
+	case *OnePayload:
+		if p.Data != nil {
+			data = []*Node{p.Data}
+		}
+		included = p.Included
+	case *ManyPayload:
+		data = p.Data
+		included = p.Included
+	default:
+		return nil
+	}
+
+	errs := []*ErrorObject{}
+	primary := map[string]string{}
+	resources := map[string]*Node{}
+
+	for i, n := range data {
+		pointer := "/" + memberData
+		if _, isMany := payload.(*ManyPayload); isMany {
+			pointer += "/" + strconv.Itoa(i)
+		}
+		if n == nil {
+			errs = append(errs, nullResourceError(pointer))
+			continue
+		}
+		errs = append(errs, validateLinkageUniqueness(n, pointer)...)
+
+		if !hasIdentity(n) {
+			continue
+		}
+		key := nodeKey(n)
+		if first, duplicate := primary[key]; duplicate {
+			errs = append(errs, linkageError(pointer, fmt.Sprintf("Resource %s is a duplicate of %s", key, first)))
+			continue
+		}
+		primary[key] = pointer
+		resources[key] = n
+	}
+
+	includedAt := map[string]string{}
+	kept := []*Node{}
+	for i, n := range included {
+		pointer := "/" + memberIncluded + "/" + strconv.Itoa(i)
+		if n == nil {
+			errs = append(errs, nullResourceError(pointer))
+			continue
+		}
+		errs = append(errs, validateLinkageUniqueness(n, pointer)...)
+
+		key := nodeKey(n)
+		if first, inData := primary[key]; inData {
+			errs = append(errs, linkageError(pointer, fmt.Sprintf("Resource %s is already the primary data %s", key, first)))
+			continue
+		}
+		if first, duplicate := includedAt[key]; duplicate {
+			errs = append(errs, linkageError(pointer, fmt.Sprintf("Resource %s is a duplicate of %s", key, first)))
+			continue
+		}
+		includedAt[key] = pointer
+		resources[key] = n
+		kept = append(kept, n)
+	}
+
+	// Walk the linkage from the primary data to find the reachable resources
+	reached := map[string]bool{}
+	queue := append([]*Node{}, data...)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == nil {
+			continue
+		}
+
+		for _, rel := range n.Relationships {
+			for _, identifier := range linkageNodes(rel) {
+				key := nodeKey(identifier)
+				if reached[key] {
+					continue
+				}
+				reached[key] = true
+				if full, ok := resources[key]; ok {
+					queue = append(queue, full)
+				}
+			}
+		}
+	}
+
+	for _, n := range kept {
+		if key := nodeKey(n); !reached[key] {
+			errs = append(errs, linkageError(includedAt[key],
+				fmt.Sprintf("Resource %s is not reachable from the primary data", key)))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// WithLinkageValidation validates the payload with ValidateLinkage before
+// writing it; if it is invalid, a 500 Internal Server Error document listing
+// the violations is written instead and ErrFullLinkage is returned.
+func WithLinkageValidation() WriteOption {
+	return func(c *writeConfig) {
+		c.validateLinkage = true
+	}
+}
+
+// validateLinkageUniqueness checks that the to-many relationships of n list
+// each resource once.
+func validateLinkageUniqueness(n *Node, pointer string) []*ErrorObject {
+	errs := []*ErrorObject{}
+
+	for _, name := range sortedKeys(n.Relationships) {
+		rel := n.Relationships[name]
+		if !isToManyLinkage(rel) {
+			continue
+		}
+
+		seen := map[string]bool{}
+		for j, identifier := range linkageNodes(rel) {
+			key := nodeKey(identifier)
+			if seen[key] {
+				errs = append(errs, linkageError(
+					fmt.Sprintf("%s/relationships/%s/data/%d", pointer, escapePointerToken(name), j),
+					fmt.Sprintf("Resource %s is listed more than once", key),
+				))
+			}
+			seen[key] = true
+		}
+	}
+
+	return errs
+}
+
+// linkageNodes returns the resource identifiers of the linkage of a
+// relationship, either built by Marshal or decoded from JSON.
+func linkageNodes(relationship interface{}) []*Node {
+	switch rel := relationship.(type) {
+	case *RelationshipOneNode:
+		if rel.Data != nil {
+			return []*Node{rel.Data}
+		}
+	case *RelationshipManyNode:
+		return rel.Data
+	case map[string]interface{}:
+		switch data := rel[memberData].(type) {
+		case map[string]interface{}:
+			return []*Node{decodeNode(data)}
+		case []interface{}:
+			nodes := []*Node{}
+			for _, d := range data {
+				if n := decodeNode(d); n != nil {
+					nodes = append(nodes, n)
+				}
+			}
+			return nodes
+		}
+	}
+	return nil
+}
+
+func isToManyLinkage(relationship interface{}) bool {
+	switch rel := relationship.(type) {
+	case *RelationshipManyNode:
+		return true
+	case map[string]interface{}:
+		_, isArray := rel[memberData].([]interface{})
+		return isArray
+	}
+	return false
+}
+
+// hasIdentity reports whether n can be identified within a document, which
+// new resources without id nor lid can't.
+func hasIdentity(n *Node) bool {
+	return n.ID != "" || n.LID != ""
+}
+
+func nullResourceError(pointer string) *ErrorObject {
+	return linkageError(pointer, "Resource object is null")
+}
+
+func linkageError(pointer, detail string) *ErrorObject {
+	return &ErrorObject{
+		Title:  linkageErrorTitle,
+		Detail: detail,
+		Source: &ErrorSource{Pointer: pointer},
+	}
+}
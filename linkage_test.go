@@ -0,0 +1,97 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func linkagePointers(errs []*ErrorObject) []string {
+	pointers := []string{}
+	for _, e := range errs {
+		pointers = append(pointers, e.Source.Pointer)
+	}
+	return pointers
+}
+
+func TestValidateLinkage_marshalledPayloads(t *testing.T) {
+	for _, models := range []interface{}{testIncludedBlog(), []*Blog{testIncludedBlog()}} {
+		payload, err := Marshal(models)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errs := ValidateLinkage(payload); errs != nil {
+			t.Fatalf("got %v, want a valid compound document", linkagePointers(errs))
+		}
+	}
+}
+
+func TestValidateLinkage_violations(t *testing.T) {
+	payload := new(ManyPayload)
+	document := `{
+		"data": [
+			{"type": "posts", "id": "1", "relationships": {
+				"comments": {"data": [{"type": "comments", "id": "1"}, {"type": "comments", "id": "1"}]}
+			}},
+			{"type": "posts", "id": "2"},
+			{"type": "posts", "id": "1"}
+		],
+		"included": [
+			{"type": "comments", "id": "1", "relationships": {
+				"author": {"data": {"type": "people", "id": "9"}}
+			}},
+			{"type": "people", "id": "9"},
+			{"type": "comments", "id": "1"},
+			{"type": "posts", "id": "2"},
+			{"type": "people", "id": "10"}
+		]
+	}`
+	if err := json.Unmarshal([]byte(document), payload); err != nil {
+		t.Fatal(err)
+	}
+
+	got := strings.Join(linkagePointers(ValidateLinkage(payload)), " ")
+	want := strings.Join([]string{
+		"/data/0/relationships/comments/data/1",
+		"/data/2",
+		"/included/2",
+		"/included/3",
+		"/included/4",
+	}, " ")
+	if got != want {
+		t.Fatalf("got violations at %s, want %s", got, want)
+	}
+}
+
+func TestValidateLinkage_nullResources(t *testing.T) {
+	for _, test := range []struct {
+		payload Payloader
+		want    string
+	}{
+		{&ManyPayload{Data: []*Node{nil}}, "/data/0"},
+		{&OnePayload{Data: &Node{Type: "posts", ID: "1"}, Included: []*Node{nil}}, "/included/0"},
+		{&OnePayload{Included: []*Node{nil}}, "/included/0"},
+	} {
+		if got := strings.Join(linkagePointers(ValidateLinkage(test.payload)), " "); got != test.want {
+			t.Errorf("got violations at %s, want %s", got, test.want)
+		}
+	}
+}
+
+func TestWithLinkageValidation(t *testing.T) {
+	payload := &OnePayload{
+		Data:     &Node{Type: "posts", ID: "1"},
+		Included: []*Node{{Type: "comments", ID: "1"}},
+	}
+
+	rr := httptest.NewRecorder()
+	if err := WritePayload(rr, http.StatusOK, payload, WithLinkageValidation()); err != ErrFullLinkage {
+		t.Fatalf("got %v, want %v", err, ErrFullLinkage)
+	}
+	if rr.Code != http.StatusInternalServerError || !bytes.Contains(rr.Body.Bytes(), []byte("/included/0")) {
+		t.Fatalf("got status %d and %s, want a 500 pointing at the unreachable resource", rr.Code, rr.Body)
+	}
+}
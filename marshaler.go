@@ -0,0 +1,241 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Marshaler is implemented by models with a generated, reflection free,
+// conversion to resource objects (see cmd/jsonapi-codegen). The runtime uses
+// it instead of reflecting over the model's jsonapi struct tags.
+type Marshaler interface {
+	// MarshalJSONAPI returns the resource object of the model, without its
+	// links and meta, which are set from Linkable and Metable.
+	MarshalJSONAPI(c *MarshalContext) (*Node, error)
+}
+
+// Unmarshaler is implemented by models with a generated, reflection free,
+// conversion from resource objects (see cmd/jsonapi-codegen). The runtime
+// uses it instead of reflecting over the model's jsonapi struct tags.
+type Unmarshaler interface {
+	// UnmarshalJSONAPI sets the model's fields from the resource object node.
+	UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error
+}
+
+// MarshalContext is passed to MarshalJSONAPI to marshal the relationships of
+// a model the way the runtime does: related resources are sideloaded into
+// the included resources, and relationship links and meta are set from
+// RelationshipLinkable and RelationshipMetable.
+type MarshalContext struct {
+	model    interface{}
+	included *includedNodes
+	sideload bool
+}
+
+// relationship returns the relationships of node, creating them if needed,
+// and the links and meta of the relationship name.
+func (c *MarshalContext) relationship(node *Node, name string) (map[string]interface{}, *Links, *Meta) {
+	if node.Relationships == nil {
+		node.Relationships = make(map[string]interface{})
+	}
+
+	var links *Links
+	if linkableModel, ok := c.model.(RelationshipLinkable); ok {
+		links = linkableModel.JSONAPIRelationshipLinks(name)
+	}
+
+	var meta *Meta
+	if metableModel, ok := c.model.(RelationshipMetable); ok {
+		meta = metableModel.JSONAPIRelationshipMeta(name)
+	}
+
+	return node.Relationships, links, meta
+}
+
+// ToOne sets the to-one relationship name of node to the related model, a
+// struct pointer, or to null if related is nil.
+func (c *MarshalContext) ToOne(node *Node, name string, related interface{}) error {
+	relationships, links, meta := c.relationship(node, name)
+
+	var n *Node
+	if related != nil {
+		var err error
+		if n, err = visitModelNode(related, c.included, c.sideload); err != nil {
+			return err
+		}
+	}
+
+	// Handle null relationship case
+	if n == nil {
+		relationships[name] = &RelationshipOneNode{Data: nil}
+		return nil
+	}
+
+	if c.sideload {
+		if err := c.included.append(n); err != nil {
+			return err
+		}
+		n = toShallowNode(n)
+	}
+
+	relationships[name] = &RelationshipOneNode{Data: n, Links: links, Meta: meta}
+	return nil
+}
+
+// ToMany sets the to-many relationship name of node to the related models,
+// struct pointers.
+func (c *MarshalContext) ToMany(node *Node, name string, related []interface{}) error {
+	relationships, links, meta := c.relationship(node, name)
+
+	nodes := []*Node{}
+	for _, model := range related {
+		n, err := visitModelNode(model, c.included, c.sideload)
+		if err != nil {
+			return err
+		}
+
+		nodes = append(nodes, n)
+	}
+
+	if c.sideload {
+		shallowNodes := []*Node{}
+		for _, n := range nodes {
+			if err := c.included.append(n); err != nil {
+				return err
+			}
+			shallowNodes = append(shallowNodes, toShallowNode(n))
+		}
+		nodes = shallowNodes
+	}
+
+	relationships[name] = &RelationshipManyNode{Data: nodes, Links: links, Meta: meta}
+	return nil
+}
+
+// UnmarshalContext is passed to UnmarshalJSONAPI to resolve relationships
+// through the included resources of the document being unmarshalled.
+type UnmarshalContext struct {
+	included *map[string]*Node
+}
+
+// CheckType returns an error if node is not a resource object of type want.
+func (c *UnmarshalContext) CheckType(node *Node, want string) error {
+	if node.Type != want {
+		return fmt.Errorf(
+			"Trying to Unmarshal an object of type %#v, but %#v does not match",
+			node.Type,
+			want,
+		)
+	}
+	return nil
+}
+
+// ToOne returns the resource object of the to-one relationship name of node,
+// completed from the included resources, or nil if the relationship is absent
+// or null.
+func (c *UnmarshalContext) ToOne(node *Node, name string) *Node {
+	var data *Node
+	switch rel := node.Relationships[name].(type) {
+	case *RelationshipOneNode:
+		data = rel.Data
+	case map[string]interface{}:
+		data = decodeNode(rel[memberData])
+	}
+
+	/*
+		http://jsonapi.org/format/#document-resource-object-relationships
+		http://jsonapi.org/format/#document-resource-object-linkage
+		relationship can have a data node set to null (e.g. to disassociate the relationship)
+		so unmarshal and set fieldValue only if data obj is not null
+	*/
+	if data == nil {
+		return nil
+	}
+
+	return fullNode(data, c.included)
+}
+
+// ToMany returns the resource objects of the to-many relationship name of
+// node, completed from the included resources, and whether the relationship
+// is present.
+func (c *UnmarshalContext) ToMany(node *Node, name string) ([]*Node, bool) {
+	var data []*Node
+	switch rel := node.Relationships[name].(type) {
+	case nil:
+		return nil, false
+	case *RelationshipManyNode:
+		data = rel.Data
+	case map[string]interface{}:
+		items, _ := rel[memberData].([]interface{})
+		for _, item := range items {
+			data = append(data, decodeNode(item))
+		}
+	}
+
+	nodes := make([]*Node, 0, len(data))
+	for _, n := range data {
+		nodes = append(nodes, fullNode(n, c.included))
+	}
+
+	return nodes, true
+}
+
+// decodeNode returns the Node of a resource object decoded from JSON, or nil
+// if v is not an object.
+func decodeNode(v interface{}) *Node {
+	object, ok := v.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	n := &Node{}
+	n.Type, _ = object["type"].(string)
+	n.ID, _ = object["id"].(string)
+	n.ClientID, _ = object["client-id"].(string)
+	n.LID, _ = object["lid"].(string)
+	n.Attributes, _ = object["attributes"].(map[string]interface{})
+	n.Relationships, _ = object["relationships"].(map[string]interface{})
+	if links, ok := object["links"].(map[string]interface{}); ok {
+		l := Links(links)
+		n.Links = &l
+	}
+	if meta, ok := object["meta"].(map[string]interface{}); ok {
+		m := Meta(meta)
+		n.Meta = &m
+	}
+	return n
+}
+
+// Unmarshal sets the fields of model, a struct pointer, from the resource
+// object node.
+func (c *UnmarshalContext) Unmarshal(node *Node, model interface{}) error {
+	return unmarshalNode(node, reflect.ValueOf(model), c.included)
+}
+
+// UnmarshalAttribute sets the field named field of model, a struct pointer,
+// from the attribute value the way the reflective conversion does. Generated
+// code uses it for the values it does not convert itself.
+func (c *UnmarshalContext) UnmarshalAttribute(model interface{}, field string, value interface{}) error {
+	modelValue := reflect.ValueOf(model).Elem()
+
+	structField, ok := modelValue.Type().FieldByName(field)
+	if !ok {
+		return fmt.Errorf("jsonapi: %s has no field %s", modelValue.Type(), field)
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	args := strings.Split(structField.Tag.Get(annotationJSONAPI), annotationSeperator)
+	fieldValue := modelValue.FieldByIndex(structField.Index)
+
+	v, err := unmarshalAttribute(value, args, structField, fieldValue)
+	if err != nil {
+		return err
+	}
+
+	assign(fieldValue, v)
+	return nil
+}
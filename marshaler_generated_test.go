@@ -0,0 +1,9 @@
+//go:build jsonapi_generated
+
+package jsonapi
+
+// The methods of models_gen_test.go, generated by cmd/jsonapi-codegen
+var (
+	_ Marshaler   = (*Blog)(nil)
+	_ Unmarshaler = (*Blog)(nil)
+)
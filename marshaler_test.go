@@ -0,0 +1,252 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+func generatedTestModels() []interface{} {
+	now := time.Date(2017, 3, 14, 15, 9, 26, 535000000, time.FixedZone("", 3600))
+	name, active, count, ratio := "name", true, 3, float32(1.5)
+	carID, carMake, year := "7", "Ford", uint(1970)
+	description, pages := "description", uint(120)
+	pointerID, custom := uint64(9), CustomIntType(4)
+	blog := testBlog()
+	blog.CreatedAt = now
+
+	return []interface{}{
+		&ModelBadTypes{ID: "1", StringField: "string", FloatField: 1.5, TimeField: now, TimePtrField: &now},
+		&ModelBadTypes{ID: "2"},
+		&WithPointer{ID: &pointerID, Name: &name, IsActive: &active, IntVal: &count, FloatVal: &ratio},
+		&WithPointer{ID: &pointerID},
+		&TimestampModel{ID: 1, DefaultV: now, DefaultP: &now, ISO8601V: now, ISO8601P: &now, RFC3339V: now, RFC3339P: &now},
+		&TimestampModel{ID: 2},
+		&Car{ID: &carID, Make: &carMake, Year: &year},
+		&Car{ID: &carID},
+		&Post{ID: 1, BlogID: 2, ClientID: "3", Title: "Title", Body: "Body", LatestComment: &Comment{ID: 4, PostID: 1}},
+		&Post{LID: "local", Title: "New", Comments: []*Comment{{LID: "comment", Body: "New"}}},
+		&Comment{ID: 1, ClientID: "2", PostID: 3, Body: "Body"},
+		&Book{ID: 1, Author: "Author", ISBN: "isbn", Title: "Title", Description: &description, Pages: &pages, Tags: []string{"a", "b"}},
+		&Book{ID: 2, PublishedAt: now},
+		blog,
+		testIncludedBlog(),
+		&Blog{ID: 3, ClientID: "4", CurrentPostID: 5, CreatedAt: now, ViewCount: 6},
+		&BadComment{ID: 1, Body: "Body"},
+		&Company{
+			ID:   "1",
+			Name: "Company",
+			Boss: Employee{Firstname: "First", Surname: "Last", Age: 50, HiredAt: &now},
+			Teams: []Team{
+				{Name: "Team", Leader: &Employee{Firstname: "Lead"}, Members: []Employee{{Firstname: "Member", Age: 20}}},
+			},
+			FoundedAt: now,
+		},
+		&Company{ID: "2"},
+		&CustomAttributeTypes{ID: "1", Int: 2, IntPtr: &custom, Float: 1.5, String: "string"},
+	}
+}
+
+// checkGolden compares got with the golden file of the test, or updates it
+// with -update. The golden files record the reflective conversion, and the
+// generated methods of models_gen_test.go, compiled with the
+// jsonapi_generated build tag, must reproduce them.
+func checkGolden(t *testing.T, got []byte) {
+	t.Helper()
+
+	golden := filepath.Join("testdata", t.Name()+".golden")
+	if *update {
+		if err := ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLines, wantLines := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	for i := 0; i < len(gotLines) || i < len(wantLines); i++ {
+		var g, w string
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if g != w {
+			t.Fatalf("%s:%d: got\n%s\nwant\n%s", golden, i+1, g, w)
+		}
+	}
+}
+
+// describeModel returns the error if any, or the fields of model as JSON.
+func describeModel(t *testing.T, model interface{}, err error) string {
+	t.Helper()
+
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	b, err := json.Marshal(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+// unmarshalModel unmarshals the document into a new model of the type of
+// model, and describes the result.
+func unmarshalModel(t *testing.T, model interface{}, document []byte) string {
+	t.Helper()
+
+	m := reflect.New(reflect.TypeOf(model).Elem()).Interface()
+	err := UnmarshalPayload(bytes.NewReader(document), m)
+	return describeModel(t, m, err)
+}
+
+func TestGeneratedMarshal(t *testing.T) {
+	got := bytes.NewBuffer(nil)
+	for _, model := range generatedTestModels() {
+		for _, marshal := range []struct {
+			name string
+			fn   func(io.Writer, interface{}) error
+		}{
+			{"MarshalPayload", MarshalPayload},
+			{"MarshalPayloadWithoutIncluded", MarshalPayloadWithoutIncluded},
+			{"MarshalOnePayloadEmbedded", MarshalOnePayloadEmbedded},
+		} {
+			out := bytes.NewBuffer(nil)
+			err := marshal.fn(out, model)
+			fmt.Fprintf(got, "%s(%T)\n%s%v\n", marshal.name, model, out, err)
+		}
+	}
+
+	blog := testBlog()
+	blog.CreatedAt = time.Date(2017, 3, 14, 15, 9, 26, 0, time.UTC)
+	out := bytes.NewBuffer(nil)
+	err := MarshalPayload(out, []*Blog{blog, testIncludedBlog()})
+	fmt.Fprintf(got, "MarshalPayload([]*Blog)\n%s%v\n", out, err)
+
+	checkGolden(t, got.Bytes())
+}
+
+func TestGeneratedUnmarshal(t *testing.T) {
+	got := bytes.NewBuffer(nil)
+	for _, model := range generatedTestModels() {
+		out := bytes.NewBuffer(nil)
+		MarshalPayload(out, model)
+
+		fmt.Fprintf(got, "%T\n%s\n", model, unmarshalModel(t, model, out.Bytes()))
+	}
+
+	checkGolden(t, got.Bytes())
+}
+
+func TestGeneratedUnmarshal_attributes(t *testing.T) {
+	got := bytes.NewBuffer(nil)
+	for _, test := range []struct {
+		model      interface{}
+		attributes map[string]interface{}
+	}{
+		{new(ModelBadTypes), map[string]interface{}{"string_field": 1.0}},
+		{new(ModelBadTypes), map[string]interface{}{"float_field": "1"}},
+		{new(ModelBadTypes), map[string]interface{}{"time_field": "now"}},
+		{new(ModelBadTypes), map[string]interface{}{"time_ptr_field": true}},
+		{new(WithPointer), map[string]interface{}{"name": "name", "is-active": false, "int-val": 8.0, "float-val": 1.25}},
+		{new(WithPointer), map[string]interface{}{"name": 1.0}},
+		{new(WithPointer), map[string]interface{}{"is-active": "true"}},
+		{new(TimestampModel), map[string]interface{}{"iso8601v": "2017-03-14T15:09:26Z", "rfc3339p": "2017-03-14T15:09:26+01:00"}},
+		{new(TimestampModel), map[string]interface{}{"iso8601v": "2017-03-14"}},
+		{new(TimestampModel), map[string]interface{}{"iso8601p": 1.0}},
+		{new(TimestampModel), map[string]interface{}{"rfc3339v": "yesterday"}},
+		{new(TimestampModel), map[string]interface{}{"defaultp": "yesterday"}},
+		{new(Book), map[string]interface{}{"tags": []interface{}{"a", "b"}, "pages": 10.0}},
+		{new(Book), map[string]interface{}{"tags": []interface{}{"a", 1.0}}},
+		{new(Book), map[string]interface{}{"description": false}},
+		{new(Company), map[string]interface{}{"boss": map[string]interface{}{"firstname": "First", "hired-at": "2017-03-14T15:09:26Z"}}},
+		{new(Company), map[string]interface{}{"teams": []interface{}{map[string]interface{}{"name": "Team"}}}},
+		{new(Company), map[string]interface{}{"boss": "boss"}},
+		{new(CustomAttributeTypes), map[string]interface{}{"int": 1.0, "intptr": 2.0, "intptrnull": nil, "float": 3.5, "string": "s"}},
+		{new(CustomAttributeTypes), map[string]interface{}{"string": 1.0}},
+	} {
+		fields, err := modelFields(reflect.TypeOf(test.model).Elem())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var resourceType string
+		for _, f := range fields {
+			if f.Annotation == annotationPrimary {
+				resourceType = f.Key
+			}
+		}
+
+		document, err := json.Marshal(map[string]interface{}{
+			"data": map[string]interface{}{"type": resourceType, "id": "1", "attributes": test.attributes},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fmt.Fprintf(got, "%T %s\n%s\n", test.model, document, unmarshalModel(t, test.model, document))
+	}
+
+	checkGolden(t, got.Bytes())
+}
+
+func TestGeneratedUnmarshal_resources(t *testing.T) {
+	got := bytes.NewBuffer(nil)
+	for _, test := range []struct {
+		model    interface{}
+		document string
+	}{
+		{new(Book), `{"data":{"type":"cars","id":"1"}}`},
+		{new(Book), `{"data":{"type":"books","id":"one"}}`},
+		{new(Car), `{"data":{"type":"cars","id":"1"}}`},
+		{new(Post), `{"data":{"type":"posts","lid":"local","attributes":{"title":"New"}}}`},
+		{new(Post), `{"data":{"type":"posts","id":"1","relationships":{"comments":{"data":[]},"latest_comment":{"data":null}}}}`},
+		{new(Post), `{"data":{"type":"posts","id":"1","relationships":{"latest_comment":{"data":{"type":"comments","id":"2"}}}},` +
+			`"included":[{"type":"comments","id":"2","attributes":{"body":"Body"}}]}`},
+		{new(Post), `{"data":{"type":"posts","id":"1","relationships":{"comments":{"data":[{"type":"posts","id":"2"}]}}}}`},
+	} {
+		fmt.Fprintf(got, "%T %s\n%s\n", test.model, test.document, unmarshalModel(t, test.model, []byte(test.document)))
+	}
+
+	checkGolden(t, got.Bytes())
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	blog := testIncludedBlog()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := Marshal(blog); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, testIncludedBlog()); err != nil {
+		b.Fatal(err)
+	}
+	document := out.Bytes()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := UnmarshalPayload(bytes.NewReader(document), new(Blog)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
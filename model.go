@@ -0,0 +1,152 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// structField describes a single jsonapi annotated field of a model struct.
+type structField struct {
+	// Index is the position of the field in the struct
+	Index int
+	// Name is the Go name of the field
+	Name string
+	// Annotation is the first argument of the tag, e.g. "primary" or "attr"
+	Annotation string
+	// Key is the second argument of the tag; the resource type for primary
+	// fields and the member name for attributes and relations
+	Key string
+	// Options holds the remaining arguments, e.g. "omitempty" or "iso8601"
+	Options []string
+	// Type is the Go type of the field
+	Type reflect.Type
+}
+
+func (f *structField) hasOption(option string) bool {
+	for _, o := range f.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// relatedType returns the struct type a relation field points to, for both
+// *Struct and []*Struct fields.
+func (f *structField) relatedType() reflect.Type {
+	t := f.Type
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// modelType resolves the struct type of a model given as a struct, a struct
+// pointer, a slice of either, or a reflect.Type of any of those.
+func modelType(model interface{}) (reflect.Type, error) {
+	t, ok := model.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(model)
+	}
+	if t == nil {
+		return nil, ErrUnexpectedType
+	}
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, ErrUnexpectedType
+	}
+	return t, nil
+}
+
+// modelFields returns the jsonapi annotated fields of the struct type t in
+// declaration order.
+func modelFields(t reflect.Type) ([]*structField, error) {
+	fields := []*structField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f, err := parseStructField(i, t.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		if f != nil {
+			fields = append(fields, f)
+		}
+	}
+
+	return fields, nil
+}
+
+// parseStructField parses the jsonapi tag of the i-th field of a struct; it
+// returns nil for fields without one.
+func parseStructField(i int, sf reflect.StructField) (*structField, error) {
+	tag := sf.Tag.Get(annotationJSONAPI)
+	if tag == "" {
+		return nil, nil
+	}
+
+	args := strings.Split(tag, annotationSeperator)
+	annotation := args[0]
+
+	if ((annotation == annotationClientID || annotation == annotationLID) && len(args) != 1) ||
+		(annotation != annotationClientID && annotation != annotationLID && len(args) < 2) {
+		return nil, ErrBadJSONAPIStructTag
+	}
+
+	f := &structField{
+		Index:      i,
+		Name:       sf.Name,
+		Annotation: annotation,
+		Type:       sf.Type,
+	}
+	if len(args) > 1 {
+		f.Key = args[1]
+		f.Options = args[2:]
+	}
+
+	return f, nil
+}
+
+// lookupField returns the field of t annotated with the given annotation and
+// member name, or nil if there is none.
+func lookupField(t reflect.Type, annotation, key string) (*structField, error) {
+	fields, err := modelFields(t)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		if f.Annotation == annotation && f.Key == key {
+			return f, nil
+		}
+	}
+	return nil, nil
+}
+
+// ResourceType returns the JSON API type of a model, as given by the
+// "primary" annotation of its struct.
+//
+// model interface{} should be a struct, a struct pointer, a slice of struct
+// pointers or the reflect.Type of one of those.
+func ResourceType(model interface{}) (string, error) {
+	t, err := modelType(model)
+	if err != nil {
+		return "", err
+	}
+
+	fields, err := modelFields(t)
+	if err != nil {
+		return "", err
+	}
+	for _, f := range fields {
+		if f.Annotation == annotationPrimary {
+			return f.Key, nil
+		}
+	}
+
+	return "", fmt.Errorf("jsonapi: %s has no %q annotated field", t, annotationPrimary)
+}
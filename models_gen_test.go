@@ -0,0 +1,949 @@
+//go:build jsonapi_generated
+
+// Code generated by jsonapi-codegen. DO NOT EDIT.
+
+package jsonapi
+
+import (
+	"strconv"
+	"time"
+)
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *ModelBadTypes) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "badtypes"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = m.ID
+	node.Attributes["string_field"] = m.StringField
+	node.Attributes["float_field"] = m.FloatField
+	if !m.TimeField.IsZero() {
+		node.Attributes["time_field"] = m.TimeField.Unix()
+	}
+	if m.TimePtrField != nil {
+		node.Attributes["time_ptr_field"] = m.TimePtrField.Unix()
+	} else {
+		node.Attributes["time_ptr_field"] = nil
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *ModelBadTypes) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "badtypes"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		m.ID = node.ID
+	}
+	switch v := node.Attributes["string_field"].(type) {
+	case nil:
+	case string:
+		m.StringField = v
+	default:
+		if err := c.UnmarshalAttribute(m, "StringField", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["float_field"].(type) {
+	case nil:
+	case float64:
+		m.FloatField = v
+	default:
+		if err := c.UnmarshalAttribute(m, "FloatField", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["time_field"].(type) {
+	case nil:
+	case float64:
+		m.TimeField = time.Unix(int64(v), 0)
+	default:
+		if err := c.UnmarshalAttribute(m, "TimeField", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["time_ptr_field"].(type) {
+	case nil:
+	case float64:
+		value := time.Unix(int64(v), 0)
+		m.TimePtrField = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "TimePtrField", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *WithPointer) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "with-pointers"}
+	node.Attributes = make(map[string]interface{})
+
+	if m.ID != nil {
+		node.ID = strconv.FormatUint(uint64(*m.ID), 10)
+	}
+	node.Attributes["name"] = m.Name
+	node.Attributes["is-active"] = m.IsActive
+	node.Attributes["int-val"] = m.IntVal
+	node.Attributes["float-val"] = m.FloatVal
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *WithPointer) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "with-pointers"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		value := uint64(id)
+		m.ID = &value
+	}
+	switch v := node.Attributes["name"].(type) {
+	case nil:
+	case string:
+		m.Name = &v
+	default:
+		if err := c.UnmarshalAttribute(m, "Name", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["is-active"].(type) {
+	case nil:
+	case bool:
+		m.IsActive = &v
+	default:
+		if err := c.UnmarshalAttribute(m, "IsActive", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["int-val"].(type) {
+	case nil:
+	case float64:
+		value := int(v)
+		m.IntVal = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "IntVal", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["float-val"].(type) {
+	case nil:
+	case float64:
+		value := float32(v)
+		m.FloatVal = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "FloatVal", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *TimestampModel) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "timestamps"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = strconv.FormatInt(int64(m.ID), 10)
+	if !m.DefaultV.IsZero() {
+		node.Attributes["defaultv"] = m.DefaultV.Unix()
+	}
+	if m.DefaultP != nil {
+		node.Attributes["defaultp"] = m.DefaultP.Unix()
+	} else {
+		node.Attributes["defaultp"] = nil
+	}
+	if !m.ISO8601V.IsZero() {
+		node.Attributes["iso8601v"] = m.ISO8601V.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	if m.ISO8601P != nil {
+		node.Attributes["iso8601p"] = m.ISO8601P.UTC().Format("2006-01-02T15:04:05Z")
+	} else {
+		node.Attributes["iso8601p"] = nil
+	}
+	if !m.RFC3339V.IsZero() {
+		node.Attributes["rfc3339v"] = m.RFC3339V.UTC().Format(time.RFC3339)
+	}
+	if m.RFC3339P != nil {
+		node.Attributes["rfc3339p"] = m.RFC3339P.UTC().Format(time.RFC3339)
+	} else {
+		node.Attributes["rfc3339p"] = nil
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *TimestampModel) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "timestamps"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = int(id)
+	}
+	switch v := node.Attributes["defaultv"].(type) {
+	case nil:
+	case float64:
+		m.DefaultV = time.Unix(int64(v), 0)
+	default:
+		if err := c.UnmarshalAttribute(m, "DefaultV", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["defaultp"].(type) {
+	case nil:
+	case float64:
+		value := time.Unix(int64(v), 0)
+		m.DefaultP = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "DefaultP", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["iso8601v"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse("2006-01-02T15:04:05Z", v)
+		if err != nil {
+			return ErrInvalidISO8601
+		}
+		m.ISO8601V = t
+	default:
+		if err := c.UnmarshalAttribute(m, "ISO8601V", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["iso8601p"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse("2006-01-02T15:04:05Z", v)
+		if err != nil {
+			return ErrInvalidISO8601
+		}
+		m.ISO8601P = &t
+	default:
+		if err := c.UnmarshalAttribute(m, "ISO8601P", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["rfc3339v"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return ErrInvalidRFC3339
+		}
+		m.RFC3339V = t
+	default:
+		if err := c.UnmarshalAttribute(m, "RFC3339V", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["rfc3339p"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return ErrInvalidRFC3339
+		}
+		m.RFC3339P = &t
+	default:
+		if err := c.UnmarshalAttribute(m, "RFC3339P", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Car) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "cars"}
+	node.Attributes = make(map[string]interface{})
+
+	if m.ID != nil {
+		node.ID = *m.ID
+	}
+	if m.Make != nil {
+		node.Attributes["make"] = m.Make
+	}
+	if m.Model != nil {
+		node.Attributes["model"] = m.Model
+	}
+	if m.Year != nil {
+		node.Attributes["year"] = m.Year
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Car) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "cars"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		value := node.ID
+		m.ID = &value
+	}
+	switch v := node.Attributes["make"].(type) {
+	case nil:
+	case string:
+		m.Make = &v
+	default:
+		if err := c.UnmarshalAttribute(m, "Make", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["model"].(type) {
+	case nil:
+	case string:
+		m.Model = &v
+	default:
+		if err := c.UnmarshalAttribute(m, "Model", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["year"].(type) {
+	case nil:
+	case float64:
+		value := uint(v)
+		m.Year = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "Year", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Post) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "posts"}
+	node.Attributes = make(map[string]interface{})
+	var related []interface{}
+
+	node.ID = strconv.FormatUint(uint64(m.ID), 10)
+	node.Attributes["blog_id"] = m.BlogID
+	node.ClientID = m.ClientID
+	node.LID = m.LID
+	node.Attributes["title"] = m.Title
+	node.Attributes["body"] = m.Body
+	related = make([]interface{}, len(m.Comments))
+	for i, model := range m.Comments {
+		related[i] = model
+	}
+	if err := c.ToMany(node, "comments", related); err != nil {
+		return nil, err
+	}
+	if err := c.ToOne(node, "latest_comment", m.LatestComment); err != nil {
+		return nil, err
+	}
+
+	// A resource identified by its local identifier has no id yet
+	if node.LID != "" && m.ID == 0 {
+		node.ID = ""
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Post) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "posts"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = uint64(id)
+	}
+	switch v := node.Attributes["blog_id"].(type) {
+	case nil:
+	case float64:
+		m.BlogID = int(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "BlogID", v); err != nil {
+			return err
+		}
+	}
+	if node.ClientID != "" {
+		m.ClientID = node.ClientID
+	}
+	if node.LID != "" {
+		m.LID = node.LID
+	}
+	switch v := node.Attributes["title"].(type) {
+	case nil:
+	case string:
+		m.Title = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Title", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["body"].(type) {
+	case nil:
+	case string:
+		m.Body = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Body", v); err != nil {
+			return err
+		}
+	}
+	if nodes, ok := c.ToMany(node, "comments"); ok {
+		var related []*Comment
+		for _, n := range nodes {
+			model := new(Comment)
+			if err := c.Unmarshal(n, model); err != nil {
+				return err
+			}
+			related = append(related, model)
+		}
+		m.Comments = related
+	}
+	if n := c.ToOne(node, "latest_comment"); n != nil {
+		model := new(Comment)
+		if err := c.Unmarshal(n, model); err != nil {
+			return err
+		}
+		m.LatestComment = model
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Comment) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "comments"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = strconv.FormatInt(int64(m.ID), 10)
+	node.ClientID = m.ClientID
+	node.LID = m.LID
+	node.Attributes["post_id"] = m.PostID
+	node.Attributes["body"] = m.Body
+
+	// A resource identified by its local identifier has no id yet
+	if node.LID != "" && m.ID == 0 {
+		node.ID = ""
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Comment) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "comments"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = int(id)
+	}
+	if node.ClientID != "" {
+		m.ClientID = node.ClientID
+	}
+	if node.LID != "" {
+		m.LID = node.LID
+	}
+	switch v := node.Attributes["post_id"].(type) {
+	case nil:
+	case float64:
+		m.PostID = int(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "PostID", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["body"].(type) {
+	case nil:
+	case string:
+		m.Body = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Body", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Book) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "books"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = strconv.FormatUint(uint64(m.ID), 10)
+	node.Attributes["author"] = m.Author
+	node.Attributes["isbn"] = m.ISBN
+	if m.Title != "" {
+		node.Attributes["title"] = m.Title
+	}
+	node.Attributes["description"] = m.Description
+	if m.Pages != nil {
+		node.Attributes["pages"] = m.Pages
+	}
+	node.Attributes["tags"] = m.Tags
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Book) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "books"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = uint64(id)
+	}
+	switch v := node.Attributes["author"].(type) {
+	case nil:
+	case string:
+		m.Author = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Author", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["isbn"].(type) {
+	case nil:
+	case string:
+		m.ISBN = v
+	default:
+		if err := c.UnmarshalAttribute(m, "ISBN", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["title"].(type) {
+	case nil:
+	case string:
+		m.Title = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Title", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["description"].(type) {
+	case nil:
+	case string:
+		m.Description = &v
+	default:
+		if err := c.UnmarshalAttribute(m, "Description", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["pages"].(type) {
+	case nil:
+	case float64:
+		value := uint(v)
+		m.Pages = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "Pages", v); err != nil {
+			return err
+		}
+	}
+	if err := c.UnmarshalAttribute(m, "Tags", node.Attributes["tags"]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Blog) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "blogs"}
+	node.Attributes = make(map[string]interface{})
+	var related []interface{}
+
+	node.ID = strconv.FormatInt(int64(m.ID), 10)
+	node.ClientID = m.ClientID
+	node.Attributes["title"] = m.Title
+	related = make([]interface{}, len(m.Posts))
+	for i, model := range m.Posts {
+		related[i] = model
+	}
+	if err := c.ToMany(node, "posts", related); err != nil {
+		return nil, err
+	}
+	if err := c.ToOne(node, "current_post", m.CurrentPost); err != nil {
+		return nil, err
+	}
+	node.Attributes["current_post_id"] = m.CurrentPostID
+	if !m.CreatedAt.IsZero() {
+		node.Attributes["created_at"] = m.CreatedAt.Unix()
+	}
+	node.Attributes["view_count"] = m.ViewCount
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Blog) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "blogs"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = int(id)
+	}
+	if node.ClientID != "" {
+		m.ClientID = node.ClientID
+	}
+	switch v := node.Attributes["title"].(type) {
+	case nil:
+	case string:
+		m.Title = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Title", v); err != nil {
+			return err
+		}
+	}
+	if nodes, ok := c.ToMany(node, "posts"); ok {
+		var related []*Post
+		for _, n := range nodes {
+			model := new(Post)
+			if err := c.Unmarshal(n, model); err != nil {
+				return err
+			}
+			related = append(related, model)
+		}
+		m.Posts = related
+	}
+	if n := c.ToOne(node, "current_post"); n != nil {
+		model := new(Post)
+		if err := c.Unmarshal(n, model); err != nil {
+			return err
+		}
+		m.CurrentPost = model
+	}
+	switch v := node.Attributes["current_post_id"].(type) {
+	case nil:
+	case float64:
+		m.CurrentPostID = int(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "CurrentPostID", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["created_at"].(type) {
+	case nil:
+	case float64:
+		m.CreatedAt = time.Unix(int64(v), 0)
+	default:
+		if err := c.UnmarshalAttribute(m, "CreatedAt", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["view_count"].(type) {
+	case nil:
+	case float64:
+		m.ViewCount = int(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "ViewCount", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *BadComment) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "bad-comment"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = strconv.FormatUint(uint64(m.ID), 10)
+	node.Attributes["body"] = m.Body
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *BadComment) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "bad-comment"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		id, err := strconv.ParseFloat(node.ID, 64)
+		if err != nil {
+			return ErrBadJSONAPIID
+		}
+		m.ID = uint64(id)
+	}
+	switch v := node.Attributes["body"].(type) {
+	case nil:
+	case string:
+		m.Body = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Body", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Company) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "companies"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = m.ID
+	node.Attributes["name"] = m.Name
+	node.Attributes["boss"] = m.Boss
+	node.Attributes["teams"] = m.Teams
+	if !m.FoundedAt.IsZero() {
+		node.Attributes["founded-at"] = m.FoundedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Company) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "companies"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		m.ID = node.ID
+	}
+	switch v := node.Attributes["name"].(type) {
+	case nil:
+	case string:
+		m.Name = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Name", v); err != nil {
+			return err
+		}
+	}
+	if err := c.UnmarshalAttribute(m, "Boss", node.Attributes["boss"]); err != nil {
+		return err
+	}
+	if err := c.UnmarshalAttribute(m, "Teams", node.Attributes["teams"]); err != nil {
+		return err
+	}
+	switch v := node.Attributes["founded-at"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse("2006-01-02T15:04:05Z", v)
+		if err != nil {
+			return ErrInvalidISO8601
+		}
+		m.FoundedAt = t
+	default:
+		if err := c.UnmarshalAttribute(m, "FoundedAt", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Team) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := new(Node)
+	node.Attributes = make(map[string]interface{})
+
+	node.Attributes["name"] = m.Name
+	node.Attributes["leader"] = m.Leader
+	node.Attributes["members"] = m.Members
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Team) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	switch v := node.Attributes["name"].(type) {
+	case nil:
+	case string:
+		m.Name = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Name", v); err != nil {
+			return err
+		}
+	}
+	if err := c.UnmarshalAttribute(m, "Leader", node.Attributes["leader"]); err != nil {
+		return err
+	}
+	if err := c.UnmarshalAttribute(m, "Members", node.Attributes["members"]); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *Employee) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := new(Node)
+	node.Attributes = make(map[string]interface{})
+
+	node.Attributes["firstname"] = m.Firstname
+	node.Attributes["surname"] = m.Surname
+	node.Attributes["age"] = m.Age
+	if m.HiredAt != nil {
+		node.Attributes["hired-at"] = m.HiredAt.UTC().Format("2006-01-02T15:04:05Z")
+	} else {
+		node.Attributes["hired-at"] = nil
+	}
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *Employee) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	switch v := node.Attributes["firstname"].(type) {
+	case nil:
+	case string:
+		m.Firstname = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Firstname", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["surname"].(type) {
+	case nil:
+	case string:
+		m.Surname = v
+	default:
+		if err := c.UnmarshalAttribute(m, "Surname", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["age"].(type) {
+	case nil:
+	case float64:
+		m.Age = int(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "Age", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["hired-at"].(type) {
+	case nil:
+	case string:
+		t, err := time.Parse("2006-01-02T15:04:05Z", v)
+		if err != nil {
+			return ErrInvalidISO8601
+		}
+		m.HiredAt = &t
+	default:
+		if err := c.UnmarshalAttribute(m, "HiredAt", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSONAPI implements jsonapi.Marshaler.
+func (m *CustomAttributeTypes) MarshalJSONAPI(c *MarshalContext) (*Node, error) {
+	node := &Node{Type: "customtypes"}
+	node.Attributes = make(map[string]interface{})
+
+	node.ID = m.ID
+	node.Attributes["int"] = m.Int
+	node.Attributes["intptr"] = m.IntPtr
+	node.Attributes["intptrnull"] = m.IntPtrNull
+	node.Attributes["float"] = m.Float
+	node.Attributes["string"] = m.String
+
+	return node, nil
+}
+
+// UnmarshalJSONAPI implements jsonapi.Unmarshaler.
+func (m *CustomAttributeTypes) UnmarshalJSONAPI(c *UnmarshalContext, node *Node) error {
+	if err := c.CheckType(node, "customtypes"); err != nil {
+		return err
+	}
+
+	if node.ID != "" {
+		m.ID = node.ID
+	}
+	switch v := node.Attributes["int"].(type) {
+	case nil:
+	case float64:
+		m.Int = CustomIntType(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "Int", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["intptr"].(type) {
+	case nil:
+	case float64:
+		value := CustomIntType(v)
+		m.IntPtr = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "IntPtr", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["intptrnull"].(type) {
+	case nil:
+	case float64:
+		value := CustomIntType(v)
+		m.IntPtrNull = &value
+	default:
+		if err := c.UnmarshalAttribute(m, "IntPtrNull", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["float"].(type) {
+	case nil:
+	case float64:
+		m.Float = CustomFloatType(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "Float", v); err != nil {
+			return err
+		}
+	}
+	switch v := node.Attributes["string"].(type) {
+	case nil:
+	case string:
+		m.String = CustomStringType(v)
+	default:
+		if err := c.UnmarshalAttribute(m, "String", v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,5 +1,7 @@
 package jsonapi
 
+//go:generate go run ./cmd/jsonapi-codegen -type ModelBadTypes,WithPointer,TimestampModel,Car,Post,Comment,Book,Blog,BadComment,Company,Team,Employee,CustomAttributeTypes -o models_gen_test.go -tags jsonapi_generated
+
 import (
 	"fmt"
 	"time"
@@ -47,6 +49,7 @@ type Post struct {
 	ID            uint64     `jsonapi:"primary,posts"`
 	BlogID        int        `jsonapi:"attr,blog_id"`
 	ClientID      string     `jsonapi:"client-id"`
+	LID           string     `jsonapi:"lid"`
 	Title         string     `jsonapi:"attr,title"`
 	Body          string     `jsonapi:"attr,body"`
 	Comments      []*Comment `jsonapi:"relation,comments"`
@@ -56,6 +59,7 @@ type Post struct {
 type Comment struct {
 	ID       int    `jsonapi:"primary,comments"`
 	ClientID string `jsonapi:"client-id"`
+	LID      string `jsonapi:"lid"`
 	PostID   int    `jsonapi:"attr,post_id"`
 	Body     string `jsonapi:"attr,body"`
 }
@@ -0,0 +1,264 @@
+package jsonapi
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerAccept      = "Accept"
+	headerContentType = "Content-Type"
+	headerVary        = "Vary"
+
+	mediaTypeParamExt     = "ext"
+	mediaTypeParamProfile = "profile"
+	acceptParamQuality    = "q"
+)
+
+// MediaTypeParams holds the "ext" and "profile" parameters of the JSON API
+// media type; both are lists of URIs.
+//
+// http://jsonapi.org/format/#media-type-parameter-rules
+type MediaTypeParams struct {
+	Extensions []string
+	Profiles   []string
+}
+
+// String formats the JSON API media type with the parameters, e.g.
+// application/vnd.api+json; ext="https://jsonapi.org/ext/atomic"
+func (p MediaTypeParams) String() string {
+	params := map[string]string{}
+	if len(p.Extensions) > 0 {
+		params[mediaTypeParamExt] = strings.Join(p.Extensions, " ")
+	}
+	if len(p.Profiles) > 0 {
+		params[mediaTypeParamProfile] = strings.Join(p.Profiles, " ")
+	}
+	if len(params) == 0 {
+		return MediaType
+	}
+	return mime.FormatMediaType(MediaType, params)
+}
+
+// Negotiation is the outcome of content negotiation for a request, see
+// ContentNegotiator.
+type Negotiation struct {
+	// Request holds the extensions and profiles applied to the request
+	// document, as given by its Content-Type header.
+	Request MediaTypeParams
+	// Response holds the extensions and profiles the client accepts and the
+	// server supports; they are advertised in the response Content-Type.
+	Response MediaTypeParams
+}
+
+type negotiationKey struct{}
+
+// NegotiationFromRequest returns the Negotiation stored in the request
+// context by ContentNegotiator, or nil if the request did not go through
+// one.
+func NegotiationFromRequest(r *http.Request) *Negotiation {
+	n, _ := r.Context().Value(negotiationKey{}).(*Negotiation)
+	return n
+}
+
+// ContentNegotiator implements the content negotiation rules of the spec as
+// an http.Handler middleware:
+//
+//   - requests whose Content-Type is the JSON API media type with parameters
+//     other than "ext" and "profile", or with an unsupported extension, are
+//     answered with 415 Unsupported Media Type.
+//   - requests whose Accept header contains the JSON API media type, but where
+//     every instance of it has parameters other than "ext" and "profile", or
+//     unsupported extensions, are answered with 406 Not Acceptable.
+//   - the Content-Type header of the response is set to the JSON API media
+//     type, along with the negotiated extensions and profiles, unless the
+//     handler sets it itself.
+//
+// http://jsonapi.org/format/#content-negotiation
+type ContentNegotiator struct {
+	// Extensions lists the URIs of the extensions supported by the server.
+	Extensions []string
+	// Profiles lists the URIs of the profiles supported by the server.
+	// Unsupported profiles are ignored, as the spec allows.
+	Profiles []string
+}
+
+// NegotiateContent wraps next in a ContentNegotiator that supports no
+// extensions or profiles.
+func NegotiateContent(next http.Handler) http.Handler {
+	return (&ContentNegotiator{}).Handler(next)
+}
+
+// Handler returns an http.Handler that negotiates the content of each
+// request before calling next. The Negotiation is available to next through
+// NegotiationFromRequest.
+func (c *ContentNegotiator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(headerVary, headerAccept)
+
+		requestParams, ok := c.negotiateContentType(r.Header.Get(headerContentType))
+		if !ok {
+			writeNegotiationError(w, http.StatusUnsupportedMediaType,
+				"Unsupported Media Type",
+				"The Content-Type header must be the JSON API media type with supported ext and profile parameters only",
+			)
+			return
+		}
+
+		responseParams, ok := c.negotiateAccept(r.Header.Values(headerAccept))
+		if !ok {
+			writeNegotiationError(w, http.StatusNotAcceptable,
+				"Not Acceptable",
+				"The Accept header must contain the JSON API media type with supported ext and profile parameters only",
+			)
+			return
+		}
+
+		n := &Negotiation{Request: requestParams, Response: responseParams}
+		r = r.WithContext(context.WithValue(r.Context(), negotiationKey{}, n))
+
+		next.ServeHTTP(&negotiatedResponseWriter{
+			ResponseWriter: w,
+			contentType:    responseParams.String(),
+		}, r)
+	})
+}
+
+// negotiateContentType returns the parameters of a Content-Type header, and
+// false if the server can't process a document of that type.
+func (c *ContentNegotiator) negotiateContentType(contentType string) (MediaTypeParams, bool) {
+	if contentType == "" {
+		return MediaTypeParams{}, true
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != MediaType {
+		// Other media types are not governed by the spec
+		return MediaTypeParams{}, true
+	}
+
+	return c.supportedParams(params)
+}
+
+// negotiateAccept returns the parameters of the first instance of the JSON
+// API media type in the Accept headers that the server supports, and false if
+// there are instances but none of them is supported.
+func (c *ContentNegotiator) negotiateAccept(accept []string) (MediaTypeParams, bool) {
+	var instances int
+
+	for _, header := range accept {
+		for _, mediaRange := range strings.Split(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(mediaRange)
+			if err != nil || mediaType != MediaType {
+				continue
+			}
+			instances++
+
+			// The quality value is an accept parameter rather than a media
+			// type parameter
+			if q, err := strconv.ParseFloat(params[acceptParamQuality], 64); err == nil && q == 0 {
+				continue
+			}
+			delete(params, acceptParamQuality)
+
+			if p, ok := c.supportedParams(params); ok {
+				return p, true
+			}
+		}
+	}
+
+	return MediaTypeParams{}, instances == 0
+}
+
+func (c *ContentNegotiator) supportedParams(params map[string]string) (MediaTypeParams, bool) {
+	var p MediaTypeParams
+
+	for name, value := range params {
+		switch name {
+		case mediaTypeParamExt:
+			for _, ext := range strings.Fields(value) {
+				if !containsString(c.Extensions, ext) {
+					return MediaTypeParams{}, false
+				}
+				p.Extensions = append(p.Extensions, ext)
+			}
+		case mediaTypeParamProfile:
+			for _, profile := range strings.Fields(value) {
+				if containsString(c.Profiles, profile) {
+					p.Profiles = append(p.Profiles, profile)
+				}
+			}
+		default:
+			return MediaTypeParams{}, false
+		}
+	}
+
+	return p, true
+}
+
+// negotiatedResponseWriter sets the negotiated Content-Type header before the
+// headers are written, unless the handler has set one.
+type negotiatedResponseWriter struct {
+	http.ResponseWriter
+	contentType string
+	wroteHeader bool
+}
+
+func (w *negotiatedResponseWriter) WriteHeader(statusCode int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if w.Header().Get(headerContentType) == "" &&
+			statusCode != http.StatusNoContent && statusCode != http.StatusNotModified {
+			w.Header().Set(headerContentType, w.contentType)
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *negotiatedResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// useDefaultContentType implements contentTypeWriter: the negotiated
+// Content-Type is set when the headers are written.
+func (w *negotiatedResponseWriter) useDefaultContentType() {}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (w *negotiatedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (w *negotiatedResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		f.Flush()
+	}
+}
+
+func writeNegotiationError(w http.ResponseWriter, status int, title, detail string) {
+	w.Header().Set(headerContentType, MediaType)
+	w.WriteHeader(status)
+	MarshalErrors(w, []*ErrorObject{{
+		Title:  title,
+		Detail: detail,
+		Status: strconv.Itoa(status),
+	}})
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
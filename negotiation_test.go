@@ -0,0 +1,181 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const testExtension = "https://jsonapi.org/ext/atomic"
+
+func TestContentNegotiator(t *testing.T) {
+	negotiator := &ContentNegotiator{
+		Extensions: []string{testExtension},
+		Profiles:   []string{"https://example.com/profiles/timestamps"},
+	}
+
+	for _, tc := range []struct {
+		desc        string
+		contentType string
+		accept      []string
+		status      int
+		responseCT  string
+	}{
+		{
+			desc:       "no headers",
+			status:     http.StatusOK,
+			responseCT: MediaType,
+		},
+		{
+			desc:        "plain media type",
+			contentType: MediaType,
+			accept:      []string{MediaType},
+			status:      http.StatusOK,
+			responseCT:  MediaType,
+		},
+		{
+			desc:        "other content type",
+			contentType: "application/json",
+			status:      http.StatusOK,
+			responseCT:  MediaType,
+		},
+		{
+			desc:        "content type with unknown parameter",
+			contentType: MediaType + "; charset=utf-8",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "content type with unsupported extension",
+			contentType: MediaType + `; ext="https://example.com/ext/bulk"`,
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			desc:        "content type with supported extension",
+			contentType: MediaType + `; ext="` + testExtension + `"`,
+			status:      http.StatusOK,
+			responseCT:  MediaType,
+		},
+		{
+			desc:        "content type with unknown profile",
+			contentType: MediaType + `; profile="https://example.com/profiles/unknown"`,
+			status:      http.StatusOK,
+			responseCT:  MediaType,
+		},
+		{
+			desc:   "all accept instances with parameters",
+			accept: []string{MediaType + "; charset=utf-8", MediaType + "; version=1"},
+			status: http.StatusNotAcceptable,
+		},
+		{
+			desc:       "one accept instance without parameters",
+			accept:     []string{MediaType + "; charset=utf-8, " + MediaType},
+			status:     http.StatusOK,
+			responseCT: MediaType,
+		},
+		{
+			desc:       "accept other media types",
+			accept:     []string{"text/html, */*"},
+			status:     http.StatusOK,
+			responseCT: MediaType,
+		},
+		{
+			desc:       "accept with quality",
+			accept:     []string{MediaType + "; q=0.9"},
+			status:     http.StatusOK,
+			responseCT: MediaType,
+		},
+		{
+			desc:   "accept with zero quality",
+			accept: []string{MediaType + "; q=0"},
+			status: http.StatusNotAcceptable,
+		},
+		{
+			desc:       "accept with supported extension and profile",
+			accept:     []string{MediaType + `; ext="` + testExtension + `"; profile="https://example.com/profiles/timestamps"`},
+			status:     http.StatusOK,
+			responseCT: MediaType + `; ext="` + testExtension + `"; profile="https://example.com/profiles/timestamps"`,
+		},
+		{
+			desc:   "accept with unsupported extension",
+			accept: []string{MediaType + `; ext="https://example.com/ext/bulk"`},
+			status: http.StatusNotAcceptable,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var called bool
+			handler := negotiator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				if NegotiationFromRequest(r) == nil {
+					t.Fatal("Expected the negotiation in the request context")
+				}
+				w.Write([]byte("{}"))
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/blogs", nil)
+			if tc.contentType != "" {
+				r.Header.Set(headerContentType, tc.contentType)
+			}
+			for _, accept := range tc.accept {
+				r.Header.Add(headerAccept, accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, r)
+
+			if got, want := rr.Code, tc.status; got != want {
+				t.Fatalf("got status %d, want %d", got, want)
+			}
+			if got, want := called, tc.status == http.StatusOK; got != want {
+				t.Fatalf("got handler called %v, want %v", got, want)
+			}
+			if tc.status != http.StatusOK {
+				payload := new(ErrorsPayload)
+				if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+					t.Fatal(err)
+				}
+				if len(payload.Errors) != 1 {
+					t.Fatalf("got %d errors, want 1", len(payload.Errors))
+				}
+				return
+			}
+			if got, want := rr.Header().Get(headerContentType), tc.responseCT; got != want {
+				t.Fatalf("got Content-Type %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestContentNegotiator_requestParams(t *testing.T) {
+	var negotiation *Negotiation
+	handler := (&ContentNegotiator{Extensions: []string{testExtension}}).Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			negotiation = NegotiationFromRequest(r)
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/operations", nil)
+	r.Header.Set(headerContentType, MediaType+`; ext="`+testExtension+`"`)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got, want := negotiation.Request.Extensions, []string{testExtension}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got extensions %v, want %v", got, want)
+	}
+}
+
+func TestNegotiateContent_handlerContentType(t *testing.T) {
+	handler := NegotiateContent(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerContentType, "text/plain")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if got, want := rr.Header().Get(headerContentType), "text/plain"; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+	if got, want := rr.Header().Get(headerVary), headerAccept; got != want {
+		t.Fatalf("got Vary %q, want %q", got, want)
+	}
+}
@@ -2,7 +2,8 @@ package jsonapi
 
 import "fmt"
 
-// Payloader is used to encapsulate the One and Many payload types
+// Payloader is used to encapsulate the One and Many payload types, as well as
+// the relationship documents returned by MarshalRelationship
 type Payloader interface {
 	clearIncluded()
 }
@@ -38,6 +39,7 @@ type Node struct {
 	Type          string                 `json:"type"`
 	ID            string                 `json:"id,omitempty"`
 	ClientID      string                 `json:"client-id,omitempty"`
+	LID           string                 `json:"lid,omitempty"`
 	Attributes    map[string]interface{} `json:"attributes,omitempty"`
 	Relationships map[string]interface{} `json:"relationships,omitempty"`
 	Links         *Links                 `json:"links,omitempty"`
@@ -51,6 +53,9 @@ type RelationshipOneNode struct {
 	Meta  *Meta  `json:"meta,omitempty"`
 }
 
+// relationship documents have no "included" array
+func (p *RelationshipOneNode) clearIncluded() {}
+
 // RelationshipManyNode is used to represent a generic has many JSON API
 // relation
 type RelationshipManyNode struct {
@@ -59,6 +64,8 @@ type RelationshipManyNode struct {
 	Meta  *Meta   `json:"meta,omitempty"`
 }
 
+func (p *RelationshipManyNode) clearIncluded() {}
+
 // Links is used to represent a `links` object.
 // http://jsonapi.org/format/#document-links
 type Links map[string]interface{}
@@ -71,6 +78,9 @@ func (l *Links) validate() (err error) {
 	//    - href: a string containing the link’s URL.
 	//    - meta: a meta object containing non-standard meta-information about the
 	//            link.
+	if l == nil {
+		return
+	}
 	for k, v := range *l {
 		_, isString := v.(string)
 		_, isLink := v.(Link)
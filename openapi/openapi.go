@@ -0,0 +1,363 @@
+// Package openapi generates OpenAPI 3.1 documents describing the JSON API
+// endpoints of models annotated with jsonapi struct tags.
+//
+// For every registered model, the document has the collection (/{type}),
+// individual resource (/{type}/{id}) and relationship
+// (/{type}/{id}/relationships/{name}) endpoints served by the server
+// package, with their query parameters and the schemas of their request,
+// response and error documents:
+//
+//	doc := openapi.New("Blog API", "1.0.0")
+//	if err := doc.Register(new(Blog)); err != nil {
+//		log.Fatal(err)
+//	}
+//	json.NewEncoder(os.Stdout).Encode(doc)
+//
+// https://spec.openapis.org/oas/v3.1.0
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/jsonapi"
+)
+
+// Version is the OpenAPI version of the generated documents.
+const Version = "3.1.0"
+
+// Names of the shared components
+const (
+	errorObjectSchema   = "jsonapi.ErrorObject"
+	errorDocumentSchema = "jsonapi.ErrorDocument"
+	errorResponse       = "jsonapi.Error"
+)
+
+// Document is an OpenAPI document.
+type Document struct {
+	OpenAPI           string               `json:"openapi"`
+	Info              *Info                `json:"info"`
+	JSONSchemaDialect string               `json:"jsonSchemaDialect,omitempty"`
+	Servers           []*Server            `json:"servers,omitempty"`
+	Paths             map[string]*PathItem `json:"paths"`
+	Components        *Components          `json:"components"`
+}
+
+// Info holds the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is the base URL of the API, e.g. "https://example.com/api".
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of an endpoint.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty"`
+	Post       *Operation   `json:"post,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty"`
+}
+
+// Operation describes a method of an endpoint.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter describes a path or query parameter, or references one of the
+// components.
+type Parameter struct {
+	Ref         string          `json:"$ref,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	In          string          `json:"in,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Style       string          `json:"style,omitempty"`
+	Explode     *bool           `json:"explode,omitempty"`
+	Schema      *jsonapi.Schema `json:"schema,omitempty"`
+}
+
+// RequestBody describes the document sent to an operation.
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response describes the document returned by an operation, or references
+// one of the components.
+type Response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a document.
+type MediaType struct {
+	Schema *jsonapi.Schema `json:"schema"`
+}
+
+// Components holds the schemas, parameters and responses shared by the
+// operations.
+type Components struct {
+	Schemas    map[string]*jsonapi.Schema `json:"schemas"`
+	Parameters map[string]*Parameter      `json:"parameters"`
+	Responses  map[string]*Response       `json:"responses"`
+}
+
+// New creates a Document without any registered models.
+func New(title, version string) *Document {
+	errorDocument := &jsonapi.Schema{
+		Type: "object",
+		Properties: map[string]*jsonapi.Schema{
+			"errors": {Type: "array", Items: schemaRef(errorObjectSchema)},
+			"meta":   {Type: "object"},
+		},
+		Required: []string{"errors"},
+	}
+
+	return &Document{
+		OpenAPI:           Version,
+		Info:              &Info{Title: title, Version: version},
+		JSONSchemaDialect: jsonapi.SchemaDialect,
+		Paths:             map[string]*PathItem{},
+		Components: &Components{
+			Schemas: map[string]*jsonapi.Schema{
+				errorObjectSchema:   jsonapi.ValueSchema(jsonapi.ErrorObject{}),
+				errorDocumentSchema: errorDocument,
+			},
+			Parameters: queryParameters(),
+			Responses: map[string]*Response{
+				errorResponse: {
+					Description: "Error",
+					Content:     content(schemaRef(errorDocumentSchema)),
+				},
+			},
+		},
+	}
+}
+
+// Register adds the endpoints of the resource type of model, a struct
+// pointer with jsonapi tags, and the schemas of its resource objects.
+func (d *Document) Register(model interface{}) error {
+	resource, err := jsonapi.ResourceSchema(model)
+	if err != nil {
+		return err
+	}
+	request, err := jsonapi.ResourceSchema(model, jsonapi.AsRequest())
+	if err != nil {
+		return err
+	}
+
+	name := resource.Title
+	if _, exists := d.Components.Schemas[name]; exists {
+		return fmt.Errorf("openapi: resource type %q is already registered", name)
+	}
+	resource.Schema = ""
+	request.Schema = ""
+	d.Components.Schemas[name] = resource
+	d.Components.Schemas[name+".request"] = request
+
+	collection := "/" + name
+	item := collection + "/{id}"
+	tags := []string{name}
+
+	d.Paths[collection] = &PathItem{
+		Get: &Operation{
+			OperationID: "list." + name,
+			Summary:     "List " + name,
+			Tags:        tags,
+			Parameters: parameterRefs(jsonapi.QueryParamInclude, jsonapi.QueryParamFields, jsonapi.QueryParamSort,
+				"pageNumber", "pageSize", "pageOffset", "pageLimit", "pageCursor"),
+			Responses: responses(http.StatusOK, dataDocument(&jsonapi.Schema{Type: "array", Items: schemaRef(name)})),
+		},
+		Post: &Operation{
+			OperationID: "create." + name,
+			Summary:     "Create a resource of " + name,
+			Tags:        tags,
+			RequestBody: requestBody(dataDocument(schemaRef(name + ".request"))),
+			Responses:   responses(http.StatusCreated, dataDocument(schemaRef(name))),
+		},
+	}
+
+	d.Paths[item] = &PathItem{
+		Parameters: []*Parameter{idParameter()},
+		Get: &Operation{
+			OperationID: "show." + name,
+			Summary:     "Show a resource of " + name,
+			Tags:        tags,
+			Parameters:  parameterRefs(jsonapi.QueryParamInclude, jsonapi.QueryParamFields),
+			Responses:   responses(http.StatusOK, dataDocument(schemaRef(name))),
+		},
+		Patch: &Operation{
+			OperationID: "update." + name,
+			Summary:     "Update a resource of " + name,
+			Tags:        tags,
+			RequestBody: requestBody(dataDocument(schemaRef(name + ".request"))),
+			Responses:   responses(http.StatusOK, dataDocument(schemaRef(name))),
+		},
+		Delete: &Operation{
+			OperationID: "delete." + name,
+			Summary:     "Delete a resource of " + name,
+			Tags:        tags,
+			Responses:   responses(http.StatusNoContent, nil),
+		},
+	}
+
+	if relationships := resource.Properties["relationships"]; relationships != nil {
+		for relation, relationship := range relationships.Properties {
+			d.registerRelationship(name, relation, relationship)
+		}
+	}
+
+	return nil
+}
+
+// registerRelationship adds the endpoint of the relationship of resources
+// of type name, described by relationship in their resource schema.
+func (d *Document) registerRelationship(name, relation string, relationship *jsonapi.Schema) {
+	linkage := relationship.Properties["data"]
+	id := name + "." + relation
+	tags := []string{name}
+
+	path := &PathItem{
+		Parameters: []*Parameter{idParameter()},
+		Get: &Operation{
+			OperationID: "showRelationship." + id,
+			Summary:     "Show the " + relation + " linkage of a resource of " + name,
+			Tags:        tags,
+			Responses:   responses(http.StatusOK, dataDocument(linkage)),
+		},
+		Patch: &Operation{
+			OperationID: "updateRelationship." + id,
+			Summary:     "Replace the " + relation + " linkage of a resource of " + name,
+			Tags:        tags,
+			RequestBody: requestBody(dataDocument(linkage)),
+			Responses:   responses(http.StatusNoContent, nil),
+		},
+	}
+
+	// Members are only added to and removed from to-many relationships
+	if linkage.Type == "array" {
+		path.Post = &Operation{
+			OperationID: "addRelationship." + id,
+			Summary:     "Add to the " + relation + " of a resource of " + name,
+			Tags:        tags,
+			RequestBody: requestBody(dataDocument(linkage)),
+			Responses:   responses(http.StatusNoContent, nil),
+		}
+		path.Delete = &Operation{
+			OperationID: "removeRelationship." + id,
+			Summary:     "Remove from the " + relation + " of a resource of " + name,
+			Tags:        tags,
+			RequestBody: requestBody(dataDocument(linkage)),
+			Responses:   responses(http.StatusNoContent, nil),
+		}
+	}
+
+	d.Paths["/"+name+"/{id}/relationships/"+relation] = path
+}
+
+// queryParameters returns the query parameters of JSON API, keyed by their
+// component name.
+func queryParameters() map[string]*Parameter {
+	explode := true
+	integer := func(minimum float64) *jsonapi.Schema {
+		return &jsonapi.Schema{Type: "integer", Minimum: &minimum}
+	}
+
+	return map[string]*Parameter{
+		jsonapi.QueryParamInclude: {
+			Name:        jsonapi.QueryParamInclude,
+			In:          "query",
+			Description: "Comma separated relationship paths of the related resources to include, e.g. \"comments.author\"",
+			Schema:      &jsonapi.Schema{Type: "string"},
+		},
+		jsonapi.QueryParamFields: {
+			Name:        jsonapi.QueryParamFields,
+			In:          "query",
+			Description: "Comma separated fields to return per resource type, e.g. fields[posts]=title,body",
+			Style:       "deepObject",
+			Explode:     &explode,
+			Schema:      &jsonapi.Schema{Type: "object", AdditionalProperties: &jsonapi.Schema{Type: "string"}},
+		},
+		jsonapi.QueryParamSort: {
+			Name:        jsonapi.QueryParamSort,
+			In:          "query",
+			Description: "Comma separated sort fields, descending when prefixed with \"-\", e.g. \"-created_at,title\"",
+			Schema:      &jsonapi.Schema{Type: "string"},
+		},
+		"pageNumber": pageParameter(jsonapi.QueryParamPageNumber, "Page number, with page[size]", integer(1)),
+		"pageSize":   pageParameter(jsonapi.QueryParamPageSize, "Page size, with page[number]", integer(1)),
+		"pageOffset": pageParameter(jsonapi.QueryParamPageOffset, "Offset of the page, with page[limit]", integer(0)),
+		"pageLimit":  pageParameter(jsonapi.QueryParamPageLimit, "Page size, with page[offset]", integer(1)),
+		"pageCursor": pageParameter(jsonapi.QueryParamPageCursor, "Cursor of the page", &jsonapi.Schema{Type: "string"}),
+	}
+}
+
+func pageParameter(name, description string, schema *jsonapi.Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func idParameter() *Parameter {
+	return &Parameter{Name: "id", In: "path", Required: true, Schema: &jsonapi.Schema{Type: "string"}}
+}
+
+func parameterRefs(names ...string) []*Parameter {
+	refs := make([]*Parameter, len(names))
+	for i, name := range names {
+		refs[i] = &Parameter{Ref: "#/components/parameters/" + name}
+	}
+	return refs
+}
+
+// dataDocument describes a document whose primary data is data.
+func dataDocument(data *jsonapi.Schema) *jsonapi.Schema {
+	return &jsonapi.Schema{
+		Type: "object",
+		Properties: map[string]*jsonapi.Schema{
+			"data":     data,
+			"included": {Type: "array", Items: &jsonapi.Schema{Type: "object"}},
+			"links":    {Type: "object"},
+			"meta":     {Type: "object"},
+			"jsonapi":  {Type: "object"},
+		},
+		Required: []string{"data"},
+	}
+}
+
+func requestBody(document *jsonapi.Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: content(document)}
+}
+
+// responses returns the successful response with status, holding document
+// unless it is nil, and the error documents of the other statuses.
+func responses(status int, document *jsonapi.Schema) map[string]*Response {
+	success := &Response{Description: http.StatusText(status)}
+	if document != nil {
+		success.Content = content(document)
+	}
+
+	return map[string]*Response{
+		fmt.Sprint(status): success,
+		"default":          {Ref: "#/components/responses/" + errorResponse},
+	}
+}
+
+func content(schema *jsonapi.Schema) map[string]*MediaType {
+	return map[string]*MediaType{jsonapi.MediaType: {Schema: schema}}
+}
+
+func schemaRef(name string) *jsonapi.Schema {
+	return &jsonapi.Schema{Ref: "#/components/schemas/" + name}
+}
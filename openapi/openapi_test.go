@@ -0,0 +1,177 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/jsonapi"
+)
+
+type Article struct {
+	ID          string     `jsonapi:"primary,articles"`
+	Title       string     `jsonapi:"attr,title"`
+	PublishedAt time.Time  `jsonapi:"attr,published_at,iso8601"`
+	Author      *Person    `jsonapi:"relation,author"`
+	Comments    []*Comment `jsonapi:"relation,comments,omitempty"`
+}
+
+type Person struct {
+	ID   int    `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type Comment struct {
+	ID   int    `jsonapi:"primary,comments"`
+	Body string `jsonapi:"attr,body"`
+}
+
+func testDocument(t *testing.T) *Document {
+	t.Helper()
+	doc := New("Articles", "1.0.0")
+	for _, model := range []interface{}{new(Article), new(Person), new(Comment)} {
+		if err := doc.Register(model); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return doc
+}
+
+func TestRegister_paths(t *testing.T) {
+	doc := testDocument(t)
+
+	for path, methods := range map[string]string{
+		"/articles":                             "get post",
+		"/articles/{id}":                        "get patch delete",
+		"/articles/{id}/relationships/author":   "get patch",
+		"/articles/{id}/relationships/comments": "get post patch delete",
+		"/people":                               "get post",
+		"/people/{id}":                          "get patch delete",
+		"/comments/{id}":                        "get patch delete",
+	} {
+		item := doc.Paths[path]
+		if item == nil {
+			t.Errorf("missing path %s", path)
+			continue
+		}
+
+		got := []string{}
+		for method, op := range map[string]*Operation{"get": item.Get, "post": item.Post, "patch": item.Patch, "delete": item.Delete} {
+			if op != nil {
+				got = append(got, method)
+			}
+		}
+		for _, method := range strings.Fields(methods) {
+			if !strings.Contains(" "+strings.Join(got, " ")+" ", " "+method+" ") {
+				t.Errorf("%s: got methods %v, want %s", path, got, methods)
+			}
+		}
+		if len(got) != len(strings.Fields(methods)) {
+			t.Errorf("%s: got methods %v, want %s", path, got, methods)
+		}
+	}
+	if len(doc.Paths) != 8 {
+		t.Errorf("got %d paths, want 8", len(doc.Paths))
+	}
+}
+
+func TestRegister_operations(t *testing.T) {
+	doc := testDocument(t)
+
+	list := doc.Paths["/articles"].Get
+	names := []string{}
+	for _, p := range list.Parameters {
+		names = append(names, doc.Components.Parameters[strings.TrimPrefix(p.Ref, "#/components/parameters/")].Name)
+	}
+	want := "include fields sort page[number] page[size] page[offset] page[limit] page[cursor]"
+	if got := strings.Join(names, " "); got != want {
+		t.Fatalf("got parameters %s, want %s", got, want)
+	}
+
+	create := doc.Paths["/articles"].Post
+	body := create.RequestBody.Content[jsonapi.MediaType].Schema
+	if got := body.Properties["data"].Ref; got != "#/components/schemas/articles.request" {
+		t.Fatalf("got request data %s", got)
+	}
+	if create.Responses["201"] == nil || create.Responses["default"].Ref != "#/components/responses/jsonapi.Error" {
+		t.Fatalf("got responses %v", create.Responses)
+	}
+
+	relationship := doc.Paths["/articles/{id}/relationships/comments"].Get.Responses["200"]
+	data := relationship.Content[jsonapi.MediaType].Schema.Properties["data"]
+	if data.Type != "array" || data.Items.Properties["type"].Const != "comments" {
+		t.Fatalf("got linkage %+v, want comments identifiers", data)
+	}
+}
+
+func TestRegister_duplicate(t *testing.T) {
+	doc := testDocument(t)
+	if err := doc.Register(new(Person)); err == nil {
+		t.Fatal("want an error for a resource type registered twice")
+	}
+}
+
+func TestRegister_invalidModel(t *testing.T) {
+	type invalid struct {
+		ID   string `jsonapi:"primary,invalids"`
+		Type string `jsonapi:"attr,type"`
+	}
+	if err := New("", "").Register(new(invalid)); err == nil {
+		t.Fatal("want an error for a model with a reserved attribute name")
+	}
+}
+
+// TestDocument_references checks that every $ref of the generated document
+// resolves to one of its components.
+func TestDocument_references(t *testing.T) {
+	out, err := json.Marshal(testDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(out, &document); err != nil {
+		t.Fatal(err)
+	}
+	if document["openapi"] != "3.1.0" {
+		t.Fatalf("got openapi %v", document["openapi"])
+	}
+
+	refs := 0
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				refs++
+				if resolve(document, ref) == nil {
+					t.Errorf("unresolved $ref %s", ref)
+				}
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	walk(document)
+
+	if refs == 0 {
+		t.Fatal("want references to components")
+	}
+}
+
+func resolve(document map[string]interface{}, ref string) interface{} {
+	var current interface{} = document
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = object[token]
+	}
+	return current
+}
@@ -0,0 +1,170 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	includePathSeparator = "."
+	queryListSeparator   = ","
+	sortDescendingPrefix = "-"
+)
+
+var errEmptyPath = errors.New("is invalid, it contains an empty member name")
+
+// ValidateQuery checks the "include" and "sort" query parameters of a request
+// against the jsonapi tags of model, see ValidateInclude and ValidateSort.
+//
+// For example you could pass it, r.URL.Query() and, model, a Blog struct
+// instance in an http handler,
+//
+//	func ListBlogs(w http.ResponseWriter, r *http.Request) {
+//		if errs := jsonapi.ValidateQuery(new(Blog), r.URL.Query()); errs != nil {
+//			w.Header().Set("Content-Type", jsonapi.MediaType)
+//			w.WriteHeader(http.StatusBadRequest)
+//			jsonapi.MarshalErrors(w, errs)
+//			return
+//		}
+//
+//		// ...fetch your blogs...
+//	}
+//
+// model interface{} should be a struct, a struct pointer, a slice of struct
+// pointers or the reflect.Type of one of those.
+func ValidateQuery(model interface{}, query url.Values) []*ErrorObject {
+	var errs []*ErrorObject
+
+	if include := query.Get(QueryParamInclude); include != "" {
+		errs = append(errs, ValidateInclude(model, include)...)
+	}
+	if sort := query.Get(QueryParamSort); sort != "" {
+		errs = append(errs, ValidateSort(model, sort)...)
+	}
+
+	return errs
+}
+
+// ValidateInclude checks that every relationship path of an "include" query
+// parameter value, e.g. "comments.author,tags", can be followed through the
+// "relation" tags of model. An error object is returned for each path that
+// does not exist, so that the server can respond with 400 Bad Request as
+// required by the spec.
+//
+// http://jsonapi.org/format/#fetching-includes
+func ValidateInclude(model interface{}, include string) []*ErrorObject {
+	t, err := modelType(model)
+	if err != nil {
+		return []*ErrorObject{invalidQueryError(QueryParamInclude, err.Error())}
+	}
+
+	var errs []*ErrorObject
+	for _, path := range strings.Split(include, queryListSeparator) {
+		if _, err := followRelationshipPath(t, splitPath(path)); err != nil {
+			errs = append(errs, invalidQueryError(
+				QueryParamInclude,
+				pathErrorDetail("relationship path", path, err),
+			))
+		}
+	}
+
+	return errs
+}
+
+// ValidateSort checks that every sort field of a "sort" query parameter
+// value, e.g. "-created_at,title", names an "attr" of model. Sort fields may
+// be prefixed with "-" for descending order and may use dot-separated
+// relationship paths to sort by an attribute of a related resource, e.g.
+// "author.name".
+//
+// http://jsonapi.org/format/#fetching-sorting
+func ValidateSort(model interface{}, sort string) []*ErrorObject {
+	t, err := modelType(model)
+	if err != nil {
+		return []*ErrorObject{invalidQueryError(QueryParamSort, err.Error())}
+	}
+
+	var errs []*ErrorObject
+	for _, sortField := range strings.Split(sort, queryListSeparator) {
+		if err := validateSortField(t, sortField); err != nil {
+			errs = append(errs, invalidQueryError(
+				QueryParamSort,
+				pathErrorDetail("sort field", sortField, err),
+			))
+		}
+	}
+
+	return errs
+}
+
+func validateSortField(t reflect.Type, sortField string) error {
+	path := splitPath(strings.TrimPrefix(sortField, sortDescendingPrefix))
+
+	related, err := followRelationshipPath(t, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+
+	attr := path[len(path)-1]
+	if attr == "" {
+		return errEmptyPath
+	}
+
+	f, err := lookupField(related, annotationAttribute, attr)
+	if err != nil {
+		return err
+	}
+	if f == nil {
+		return fmt.Errorf("is invalid, %s has no attribute %q", related.Name(), attr)
+	}
+
+	return nil
+}
+
+// followRelationshipPath walks the relation fields named by path, starting
+// at t, and returns the struct type at the end of the path.
+func followRelationshipPath(t reflect.Type, path []string) (reflect.Type, error) {
+	for _, name := range path {
+		if name == "" {
+			return nil, errEmptyPath
+		}
+
+		f, err := lookupField(t, annotationRelation, name)
+		if err != nil {
+			return nil, err
+		}
+		if f == nil {
+			return nil, fmt.Errorf(
+				"is invalid, %s has no relationship %q", t.Name(), name)
+		}
+
+		t = f.relatedType()
+	}
+
+	return t, nil
+}
+
+func splitPath(path string) []string {
+	return strings.Split(path, includePathSeparator)
+}
+
+func pathErrorDetail(kind, path string, err error) string {
+	if err == ErrBadJSONAPIStructTag {
+		return err.Error()
+	}
+	return fmt.Sprintf("The %s %q %s", kind, path, err.Error())
+}
+
+func invalidQueryError(parameter, detail string) *ErrorObject {
+	return &ErrorObject{
+		Title:  "Invalid Query Parameter",
+		Detail: detail,
+		Status: strconv.Itoa(http.StatusBadRequest),
+		Source: &ErrorSource{Parameter: parameter},
+	}
+}
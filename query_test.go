@@ -0,0 +1,96 @@
+package jsonapi
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestValidateInclude(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		include string
+		invalid int
+	}{
+		{desc: "single relationship", include: "posts"},
+		{desc: "nested relationships", include: "posts.comments,current_post.latest_comment"},
+		{desc: "unknown relationship", include: "author", invalid: 1},
+		{desc: "unknown nested relationship", include: "posts.comments.author", invalid: 1},
+		{desc: "attribute is not a relationship", include: "title", invalid: 1},
+		{desc: "empty member", include: "posts..comments", invalid: 1},
+		{desc: "several invalid paths", include: "posts,author,current_post.blog", invalid: 2},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			errs := ValidateInclude(new(Blog), tc.include)
+			if got, want := len(errs), tc.invalid; got != want {
+				t.Fatalf("got %d errors, want %d: %v", got, want, errs)
+			}
+			for _, e := range errs {
+				if e.Status != "400" {
+					t.Fatalf("got status %q, want 400", e.Status)
+				}
+				if e.Source == nil || e.Source.Parameter != QueryParamInclude {
+					t.Fatalf("got source %#v, want parameter %q", e.Source, QueryParamInclude)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSort(t *testing.T) {
+	for _, tc := range []struct {
+		desc    string
+		sort    string
+		invalid int
+	}{
+		{desc: "ascending attribute", sort: "title"},
+		{desc: "descending attributes", sort: "-created_at,-view_count,title"},
+		{desc: "related attribute", sort: "-current_post.title,posts.body"},
+		{desc: "unknown attribute", sort: "-updated_at", invalid: 1},
+		{desc: "relationship is not an attribute", sort: "posts", invalid: 1},
+		{desc: "unknown relationship", sort: "author.name", invalid: 1},
+		{desc: "empty sort field", sort: "title,", invalid: 1},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			errs := ValidateSort(new(Blog), tc.sort)
+			if got, want := len(errs), tc.invalid; got != want {
+				t.Fatalf("got %d errors, want %d: %v", got, want, errs)
+			}
+			for _, e := range errs {
+				if e.Source == nil || e.Source.Parameter != QueryParamSort {
+					t.Fatalf("got source %#v, want parameter %q", e.Source, QueryParamSort)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateQuery(t *testing.T) {
+	query := url.Values{}
+	query.Set(QueryParamInclude, "posts.author")
+	query.Set(QueryParamSort, "-created_at")
+
+	errs := ValidateQuery([]*Blog{}, query)
+	if len(errs) != 1 {
+		t.Fatalf("got %d errors, want 1: %v", len(errs), errs)
+	}
+	if errs[0].Source.Parameter != QueryParamInclude {
+		t.Fatalf("got parameter %q, want %q", errs[0].Source.Parameter, QueryParamInclude)
+	}
+
+	if errs := ValidateQuery(reflect.TypeOf(new(Blog)), url.Values{}); errs != nil {
+		t.Fatalf("got %v, want no errors", errs)
+	}
+}
+
+func TestValidateQuery_badModel(t *testing.T) {
+	errs := ValidateInclude(new(BadModel), "posts")
+	if len(errs) != 1 || errs[0].Detail != ErrBadJSONAPIStructTag.Error() {
+		t.Fatalf("got %v, want a single bad tag error", errs)
+	}
+
+	errs = ValidateSort(25, "title")
+	if len(errs) != 1 || errs[0].Detail != ErrUnexpectedType.Error() {
+		t.Fatalf("got %v, want a single unexpected type error", errs)
+	}
+}
@@ -0,0 +1,327 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+)
+
+var (
+	// ErrUnknownRelationship is returned when a model has no "relation"
+	// annotated field with the requested name.
+	ErrUnknownRelationship = errors.New("jsonapi: no such relationship")
+	// ErrNotToManyRelationship is returned when members are added to or
+	// removed from a to-one relationship.
+	ErrNotToManyRelationship = errors.New("jsonapi: members can only be added to or removed from to-many relationships")
+	// ErrInvalidLinkage is returned when the "data" of a relationship document
+	// is not resource linkage matching the relationship's cardinality: null or
+	// a resource identifier object for to-one relationships, an array of
+	// resource identifier objects for to-many relationships.
+	ErrInvalidLinkage = errors.New("jsonapi: relationship data is not valid resource linkage")
+	// ErrLinkageTypeMismatch is returned when resource linkage refers to a
+	// resource whose type does not match the relationship's type.
+	ErrLinkageTypeMismatch = errors.New("jsonapi: resource linkage type does not match the relationship")
+)
+
+var jsonNull = []byte("null")
+
+// MarshalRelationship returns the relationship document of the relation
+// named relation of model, i.e. the resource linkage found at
+// /{type}/{id}/relationships/{relation}. The returned Payloader is either a
+// *RelationshipOneNode or a *RelationshipManyNode, with the links and meta
+// provided by the RelationshipLinkable and RelationshipMetable interfaces.
+//
+// http://jsonapi.org/format/#fetching-relationships
+//
+// model interface{} should be a pointer to a struct.
+func MarshalRelationship(model interface{}, relation string) (Payloader, error) {
+	_, f, err := relationField(model, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := visitModelNode(model, newIncludedNodes(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	var links *Links
+	if linkableModel, ok := model.(RelationshipLinkable); ok {
+		links = linkableModel.JSONAPIRelationshipLinks(relation)
+	}
+	var meta *Meta
+	if metableModel, ok := model.(RelationshipMetable); ok {
+		meta = metableModel.JSONAPIRelationshipMeta(relation)
+	}
+
+	switch rel := node.Relationships[relation].(type) {
+	case *RelationshipManyNode:
+		rel.Links, rel.Meta = links, meta
+		return rel, nil
+	case *RelationshipOneNode:
+		rel.Links, rel.Meta = links, meta
+		return rel, nil
+	}
+
+	// The relationship was omitted because it was empty
+	if f.Type.Kind() == reflect.Slice {
+		return &RelationshipManyNode{Data: []*Node{}, Links: links, Meta: meta}, nil
+	}
+	return &RelationshipOneNode{Links: links, Meta: meta}, nil
+}
+
+// MarshalRelationshipPayload writes the relationship document of the
+// relation named relation of model, see MarshalRelationship.
+func MarshalRelationshipPayload(w io.Writer, model interface{}, relation string) error {
+	payload, err := MarshalRelationship(model, relation)
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(payload)
+}
+
+// UnmarshalRelationship reads a relationship document from in and replaces
+// the members of the relation named relation of model with the resources it
+// identifies, as a PATCH request to /{type}/{id}/relationships/{relation}
+// does. Only the primary field of the related structs is set; null linkage
+// clears a to-one relationship and an empty array clears a to-many one.
+//
+// http://jsonapi.org/format/#crud-updating-relationships
+//
+// model interface{} should be a pointer to a struct.
+func UnmarshalRelationship(in io.Reader, model interface{}, relation string) error {
+	fieldValue, f, err := relationField(model, relation)
+	if err != nil {
+		return err
+	}
+
+	isSlice := f.Type.Kind() == reflect.Slice
+	linkage, err := decodeLinkage(in, f, isSlice)
+	if err != nil {
+		return err
+	}
+
+	if !isSlice {
+		if len(linkage) == 0 {
+			fieldValue.Set(reflect.Zero(f.Type))
+			return nil
+		}
+		m, err := linkageModel(f, linkage[0])
+		if err != nil {
+			return err
+		}
+		fieldValue.Set(m)
+		return nil
+	}
+
+	models := reflect.MakeSlice(f.Type, 0, len(linkage))
+	for _, n := range linkage {
+		m, err := linkageModel(f, n)
+		if err != nil {
+			return err
+		}
+		models = reflect.Append(models, m)
+	}
+	fieldValue.Set(models)
+
+	return nil
+}
+
+// AddRelationshipMembers reads a relationship document from in and adds the
+// resources it identifies to the to-many relation named relation of model,
+// as a POST request to /{type}/{id}/relationships/{relation} does. Resources
+// that are already members of the relationship are left untouched.
+//
+// model interface{} should be a pointer to a struct.
+func AddRelationshipMembers(in io.Reader, model interface{}, relation string) error {
+	fieldValue, f, err := toManyRelationField(model, relation)
+	if err != nil {
+		return err
+	}
+
+	linkage, err := decodeLinkage(in, f, true)
+	if err != nil {
+		return err
+	}
+
+	members, err := memberIDs(fieldValue)
+	if err != nil {
+		return err
+	}
+
+	// Copy the members so that the backing array of the field is not shared
+	models := reflect.MakeSlice(f.Type, fieldValue.Len(), fieldValue.Len()+len(linkage))
+	reflect.Copy(models, fieldValue)
+	for _, n := range linkage {
+		if members[n.ID] {
+			continue
+		}
+		members[n.ID] = true
+
+		m, err := linkageModel(f, n)
+		if err != nil {
+			return err
+		}
+		models = reflect.Append(models, m)
+	}
+	fieldValue.Set(models)
+
+	return nil
+}
+
+// RemoveRelationshipMembers reads a relationship document from in and removes
+// the resources it identifies from the to-many relation named relation of
+// model, as a DELETE request to /{type}/{id}/relationships/{relation} does.
+// Resources that are not members of the relationship are ignored.
+//
+// model interface{} should be a pointer to a struct.
+func RemoveRelationshipMembers(in io.Reader, model interface{}, relation string) error {
+	fieldValue, f, err := toManyRelationField(model, relation)
+	if err != nil {
+		return err
+	}
+
+	linkage, err := decodeLinkage(in, f, true)
+	if err != nil {
+		return err
+	}
+
+	removed := map[string]bool{}
+	for _, n := range linkage {
+		removed[n.ID] = true
+	}
+
+	models := reflect.MakeSlice(f.Type, 0, fieldValue.Len())
+	for i := 0; i < fieldValue.Len(); i++ {
+		m := fieldValue.Index(i)
+		id, err := memberID(m)
+		if err != nil {
+			return err
+		}
+		if id != "" && removed[id] {
+			continue
+		}
+		models = reflect.Append(models, m)
+	}
+	fieldValue.Set(models)
+
+	return nil
+}
+
+// relationField returns the value and description of the relation field
+// named relation of model.
+func relationField(model interface{}, relation string) (reflect.Value, *structField, error) {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, ErrUnexpectedType
+	}
+
+	f, err := lookupField(v.Elem().Type(), annotationRelation, relation)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	if f == nil {
+		return reflect.Value{}, nil, ErrUnknownRelationship
+	}
+
+	return v.Elem().Field(f.Index), f, nil
+}
+
+func toManyRelationField(model interface{}, relation string) (reflect.Value, *structField, error) {
+	fieldValue, f, err := relationField(model, relation)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	if f.Type.Kind() != reflect.Slice {
+		return reflect.Value{}, nil, ErrNotToManyRelationship
+	}
+
+	return fieldValue, f, nil
+}
+
+// decodeLinkage reads the resource identifier objects of a relationship
+// document and checks them against the relation field f.
+func decodeLinkage(in io.Reader, f *structField, toMany bool) ([]*Node, error) {
+	document := struct {
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.NewDecoder(in).Decode(&document); err != nil {
+		return nil, err
+	}
+	if document.Data == nil {
+		return nil, ErrInvalidLinkage
+	}
+
+	var linkage []*Node
+	if toMany {
+		if bytes.Equal(document.Data, jsonNull) {
+			return nil, ErrInvalidLinkage
+		}
+		if err := json.Unmarshal(document.Data, &linkage); err != nil {
+			return nil, ErrInvalidLinkage
+		}
+	} else if !bytes.Equal(document.Data, jsonNull) {
+		n := new(Node)
+		if err := json.Unmarshal(document.Data, n); err != nil {
+			return nil, ErrInvalidLinkage
+		}
+		linkage = append(linkage, n)
+	}
+
+	relatedType, err := ResourceType(f.relatedType())
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range linkage {
+		if n == nil || n.Type == "" || n.ID == "" {
+			return nil, ErrInvalidLinkage
+		}
+		if n.Type != relatedType {
+			return nil, ErrLinkageTypeMismatch
+		}
+	}
+
+	return linkage, nil
+}
+
+// linkageModel returns a new related struct pointer for the relation field f
+// with its primary field set from the resource identifier n.
+func linkageModel(f *structField, n *Node) (reflect.Value, error) {
+	m := reflect.New(f.relatedType())
+	if err := unmarshalNode(&Node{Type: n.Type, ID: n.ID}, m, nil); err != nil {
+		return reflect.Value{}, err
+	}
+
+	return m, nil
+}
+
+func memberIDs(models reflect.Value) (map[string]bool, error) {
+	ids := map[string]bool{}
+	for i := 0; i < models.Len(); i++ {
+		id, err := memberID(models.Index(i))
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			ids[id] = true
+		}
+	}
+
+	return ids, nil
+}
+
+// memberID returns the id of a related struct pointer, or "" if it is nil.
+func memberID(m reflect.Value) (string, error) {
+	if m.IsNil() {
+		return "", nil
+	}
+	node, err := visitModelNode(m.Interface(), nil, false)
+	if err != nil {
+		return "", err
+	}
+
+	return node.ID, nil
+}
@@ -0,0 +1,171 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMarshalRelationship_toMany(t *testing.T) {
+	blog := testBlog()
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalRelationshipPayload(out, blog, "posts"); err != nil {
+		t.Fatal(err)
+	}
+
+	var jsonData map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &jsonData); err != nil {
+		t.Fatal(err)
+	}
+	data, ok := jsonData["data"].([]interface{})
+	if !ok || len(data) != 2 {
+		t.Fatalf("got data %v, want an array of 2 resource identifiers", jsonData["data"])
+	}
+	identifier := data[0].(map[string]interface{})
+	if identifier["type"] != "posts" || identifier["id"] != "1" {
+		t.Fatalf("got %v, want posts/1", identifier)
+	}
+	if _, hasAttributes := identifier["attributes"]; hasAttributes {
+		t.Fatal("Resource identifiers should not have attributes")
+	}
+	if _, hasLinks := jsonData["links"]; !hasLinks {
+		t.Fatal("Expected the relationship links")
+	}
+	if _, hasMeta := jsonData["meta"]; !hasMeta {
+		t.Fatal("Expected the relationship meta")
+	}
+	if _, hasIncluded := jsonData["included"]; hasIncluded {
+		t.Fatal("Relationship documents should not have included resources")
+	}
+}
+
+func TestMarshalRelationship_toOne(t *testing.T) {
+	payload, err := MarshalRelationship(testBlog(), "current_post")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, ok := payload.(*RelationshipOneNode)
+	if !ok {
+		t.Fatalf("got %T, want *RelationshipOneNode", payload)
+	}
+	if rel.Data.Type != "posts" || rel.Data.ID != "1" {
+		t.Fatalf("got %s/%s, want posts/1", rel.Data.Type, rel.Data.ID)
+	}
+
+	payload, err = MarshalRelationship(&Post{ID: 1}, "latest_comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rel := payload.(*RelationshipOneNode); rel.Data != nil {
+		t.Fatalf("got %v, want null linkage", rel.Data)
+	}
+}
+
+func TestMarshalRelationship_unknown(t *testing.T) {
+	if _, err := MarshalRelationship(testBlog(), "authors"); err != ErrUnknownRelationship {
+		t.Fatalf("got %v, want %v", err, ErrUnknownRelationship)
+	}
+	if _, err := MarshalRelationship(Blog{}, "posts"); err != ErrUnexpectedType {
+		t.Fatalf("got %v, want %v", err, ErrUnexpectedType)
+	}
+}
+
+func TestUnmarshalRelationship(t *testing.T) {
+	post := &Post{ID: 1, LatestComment: &Comment{ID: 1}, Comments: []*Comment{{ID: 1}}}
+
+	in := strings.NewReader(`{"data": [{"type": "comments", "id": "2"}, {"type": "comments", "id": "3"}]}`)
+	if err := UnmarshalRelationship(in, post, "comments"); err != nil {
+		t.Fatal(err)
+	}
+	if len(post.Comments) != 2 || post.Comments[0].ID != 2 || post.Comments[1].ID != 3 {
+		t.Fatalf("got comments %v, want 2 and 3", post.Comments)
+	}
+
+	in = strings.NewReader(`{"data": {"type": "comments", "id": "5"}}`)
+	if err := UnmarshalRelationship(in, post, "latest_comment"); err != nil {
+		t.Fatal(err)
+	}
+	if post.LatestComment == nil || post.LatestComment.ID != 5 {
+		t.Fatalf("got latest comment %v, want 5", post.LatestComment)
+	}
+
+	in = strings.NewReader(`{"data": null}`)
+	if err := UnmarshalRelationship(in, post, "latest_comment"); err != nil {
+		t.Fatal(err)
+	}
+	if post.LatestComment != nil {
+		t.Fatalf("got latest comment %v, want nil", post.LatestComment)
+	}
+
+	in = strings.NewReader(`{"data": []}`)
+	if err := UnmarshalRelationship(in, post, "comments"); err != nil {
+		t.Fatal(err)
+	}
+	if post.Comments == nil || len(post.Comments) != 0 {
+		t.Fatalf("got comments %v, want an empty slice", post.Comments)
+	}
+}
+
+func TestUnmarshalRelationship_errors(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		relation string
+		body     string
+		want     error
+	}{
+		{desc: "unknown relationship", relation: "author", body: `{"data": null}`, want: ErrUnknownRelationship},
+		{desc: "missing data", relation: "comments", body: `{"meta": {}}`, want: ErrInvalidLinkage},
+		{desc: "null to-many", relation: "comments", body: `{"data": null}`, want: ErrInvalidLinkage},
+		{desc: "object to-many", relation: "comments", body: `{"data": {"type": "comments", "id": "1"}}`, want: ErrInvalidLinkage},
+		{desc: "array to-one", relation: "latest_comment", body: `{"data": []}`, want: ErrInvalidLinkage},
+		{desc: "missing id", relation: "comments", body: `{"data": [{"type": "comments"}]}`, want: ErrInvalidLinkage},
+		{desc: "type mismatch", relation: "comments", body: `{"data": [{"type": "posts", "id": "1"}]}`, want: ErrLinkageTypeMismatch},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := UnmarshalRelationship(strings.NewReader(tc.body), &Post{}, tc.relation)
+			if err != tc.want {
+				t.Fatalf("got %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddRelationshipMembers(t *testing.T) {
+	existing := []*Comment{{ID: 1, Body: "foo"}}
+	post := &Post{ID: 1, Comments: existing}
+
+	in := strings.NewReader(`{"data": [{"type": "comments", "id": "1"}, {"type": "comments", "id": "2"}, {"type": "comments", "id": "2"}]}`)
+	if err := AddRelationshipMembers(in, post, "comments"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(post.Comments) != 2 || post.Comments[0].ID != 1 || post.Comments[1].ID != 2 {
+		t.Fatalf("got comments %v, want 1 and 2", post.Comments)
+	}
+	if post.Comments[0].Body != "foo" {
+		t.Fatal("Existing members should be left untouched")
+	}
+	if len(existing) != 1 {
+		t.Fatal("The original slice should not be modified")
+	}
+
+	in = strings.NewReader(`{"data": [{"type": "comments", "id": "3"}]}`)
+	if err := AddRelationshipMembers(in, post, "latest_comment"); err != ErrNotToManyRelationship {
+		t.Fatalf("got %v, want %v", err, ErrNotToManyRelationship)
+	}
+}
+
+func TestRemoveRelationshipMembers(t *testing.T) {
+	post := &Post{ID: 1, Comments: []*Comment{{ID: 1}, {ID: 2}, nil, {ID: 3}}}
+
+	in := strings.NewReader(`{"data": [{"type": "comments", "id": "2"}, {"type": "comments", "id": "4"}]}`)
+	if err := RemoveRelationshipMembers(in, post, "comments"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(post.Comments) != 3 || post.Comments[0].ID != 1 || post.Comments[2].ID != 3 {
+		t.Fatalf("got comments %v, want 1, nil and 3", post.Comments)
+	}
+}
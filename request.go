@@ -1,7 +1,6 @@
 package jsonapi
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -60,8 +59,8 @@ func newErrUnsupportedPtrType(rf reflect.Value, t reflect.Type, structField refl
 }
 
 // UnmarshalPayload converts an io into a struct instance using jsonapi tags on
-// struct fields. This method supports single request payloads only, at the
-// moment. Bulk creates and updates are not supported yet.
+// struct fields. This method supports single request payloads only; see
+// UnmarshalBulkPayload for bulk creates and updates.
 //
 // Will Unmarshal embedded and sideloaded payloads.  The latter is only possible if the
 // object graph is complete.  That is, in the "relationships" data there are type and id,
@@ -93,6 +92,26 @@ func newErrUnsupportedPtrType(rf reflect.Value, t reflect.Type, structField refl
 //
 // model interface{} should be a pointer to a struct.
 func UnmarshalPayload(in io.Reader, model interface{}) error {
+	return UnmarshalPayloadWithOptions(in, model)
+}
+
+// UnmarshalPayloadWithOptions does the same as UnmarshalPayload, with
+// options. Pass the WithDocumentValidation option to reject documents that
+// are not valid JSON API documents with a *DocumentError, see
+// ValidateDocument.
+func UnmarshalPayloadWithOptions(in io.Reader, model interface{}, opts ...UnmarshalOption) error {
+	c := &unmarshalConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.validateDocument {
+		validated, err := validatedReader(in)
+		if err != nil {
+			return err
+		}
+		in = validated
+	}
+
 	payload := new(OnePayload)
 
 	if err := json.NewDecoder(in).Decode(payload); err != nil {
@@ -102,8 +121,7 @@ func UnmarshalPayload(in io.Reader, model interface{}) error {
 	if payload.Included != nil {
 		includedMap := make(map[string]*Node)
 		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
+			includedMap[nodeKey(included)] = included
 		}
 
 		return unmarshalNode(payload.Data, reflect.ValueOf(model), &includedMap)
@@ -125,8 +143,7 @@ func UnmarshalManyPayload(in io.Reader, t reflect.Type) ([]interface{}, error) {
 
 	if payload.Included != nil {
 		for _, included := range payload.Included {
-			key := fmt.Sprintf("%s,%s", included.Type, included.ID)
-			includedMap[key] = included
+			includedMap[nodeKey(included)] = included
 		}
 	}
 
@@ -149,6 +166,10 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 		}
 	}()
 
+	if unmarshaler, ok := model.Interface().(Unmarshaler); ok {
+		return unmarshaler.UnmarshalJSONAPI(&UnmarshalContext{included: included}, data)
+	}
+
 	modelValue := model.Elem()
 	modelType := modelValue.Type()
 
@@ -171,8 +192,8 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 
 		annotation := args[0]
 
-		if (annotation == annotationClientID && len(args) != 1) ||
-			(annotation != annotationClientID && len(args) < 2) {
+		if ((annotation == annotationClientID || annotation == annotationLID) && len(args) != 1) ||
+			(annotation != annotationClientID && annotation != annotationLID && len(args) < 2) {
 			er = ErrBadJSONAPIStructTag
 			break
 		}
@@ -235,6 +256,12 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 			}
 
 			fieldValue.Set(reflect.ValueOf(data.ClientID))
+		} else if annotation == annotationLID {
+			if data.LID == "" {
+				continue
+			}
+
+			fieldValue.Set(reflect.ValueOf(data.LID))
 		} else if annotation == annotationAttribute {
 			attributes := data.Attributes
 
@@ -258,32 +285,21 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 
 			assign(fieldValue, value)
 		} else if annotation == annotationRelation {
-			isSlice := fieldValue.Type().Kind() == reflect.Slice
-
-			if data.Relationships == nil || data.Relationships[args[1]] == nil {
-				continue
-			}
+			c := &UnmarshalContext{included: included}
 
-			if isSlice {
+			if fieldValue.Type().Kind() == reflect.Slice {
 				// to-many relationship
-				relationship := new(RelationshipManyNode)
-
-				buf := bytes.NewBuffer(nil)
-
-				json.NewEncoder(buf).Encode(data.Relationships[args[1]])
-				json.NewDecoder(buf).Decode(relationship)
+				related, ok := c.ToMany(data, args[1])
+				if !ok {
+					continue
+				}
 
-				data := relationship.Data
 				models := reflect.New(fieldValue.Type()).Elem()
 
-				for _, n := range data {
+				for _, n := range related {
 					m := reflect.New(fieldValue.Type().Elem().Elem())
 
-					if err := unmarshalNode(
-						fullNode(n, included),
-						m,
-						included,
-					); err != nil {
+					if err := unmarshalNode(n, m, included); err != nil {
 						er = err
 						break
 					}
@@ -294,39 +310,19 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 				fieldValue.Set(models)
 			} else {
 				// to-one relationships
-				relationship := new(RelationshipOneNode)
-
-				buf := bytes.NewBuffer(nil)
-
-				json.NewEncoder(buf).Encode(
-					data.Relationships[args[1]],
-				)
-				json.NewDecoder(buf).Decode(relationship)
-
-				/*
-					http://jsonapi.org/format/#document-resource-object-relationships
-					http://jsonapi.org/format/#document-resource-object-linkage
-					relationship can have a data node set to null (e.g. to disassociate the relationship)
-					so unmarshal and set fieldValue only if data obj is not null
-				*/
-				if relationship.Data == nil {
+				related := c.ToOne(data, args[1])
+				if related == nil {
 					continue
 				}
 
 				m := reflect.New(fieldValue.Type().Elem())
-				if err := unmarshalNode(
-					fullNode(relationship.Data, included),
-					m,
-					included,
-				); err != nil {
+				if err := unmarshalNode(related, m, included); err != nil {
 					er = err
 					break
 				}
 
 				fieldValue.Set(m)
-
 			}
-
 		} else {
 			er = fmt.Errorf(unsupportedStructTagMsg, annotation)
 		}
@@ -336,7 +332,7 @@ func unmarshalNode(data *Node, model reflect.Value, included *map[string]*Node)
 }
 
 func fullNode(n *Node, included *map[string]*Node) *Node {
-	includedKey := fmt.Sprintf("%s,%s", n.Type, n.ID)
+	includedKey := nodeKey(n)
 
 	if included != nil && (*included)[includedKey] != nil {
 		return (*included)[includedKey]
@@ -345,6 +341,16 @@ func fullNode(n *Node, included *map[string]*Node) *Node {
 	return n
 }
 
+// nodeKey identifies a resource within a document by its type and id, or by
+// its type and local identifier if it has no id yet.
+func nodeKey(n *Node) string {
+	if n.ID == "" && n.LID != "" {
+		return fmt.Sprintf("%s,lid:%s", n.Type, n.LID)
+	}
+
+	return fmt.Sprintf("%s,%s", n.Type, n.ID)
+}
+
 // assign will take the value specified and assign it to the field; if
 // field is expecting a ptr assign will assign a ptr.
 func assign(field, value reflect.Value) {
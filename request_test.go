@@ -745,6 +745,46 @@ func TestUnmarshalNestedRelationshipsEmbedded_withClientIDs(t *testing.T) {
 	}
 }
 
+func TestUnmarshalRelationshipsSideloaded_withLIDs(t *testing.T) {
+	payload := `{
+		"data": {
+			"type": "posts",
+			"lid": "post",
+			"attributes": {"title": "New"},
+			"relationships": {
+				"comments": {
+					"data": [
+						{"type": "comments", "lid": "first"},
+						{"type": "comments", "lid": "second"}
+					]
+				}
+			}
+		},
+		"included": [
+			{"type": "comments", "lid": "second", "attributes": {"body": "Second"}},
+			{"type": "comments", "lid": "first", "attributes": {"body": "First"}}
+		]
+	}`
+	out := new(Post)
+
+	if err := UnmarshalPayload(strings.NewReader(payload), out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.LID != "post" {
+		t.Fatalf("LID not set from request, got %q", out.LID)
+	}
+	if len(out.Comments) != 2 {
+		t.Fatalf("Wrong number of comments")
+	}
+	if out.Comments[0].LID != "first" || out.Comments[0].Body != "First" {
+		t.Fatalf("got %+v, want the included comment with lid first", out.Comments[0])
+	}
+	if out.Comments[1].LID != "second" || out.Comments[1].Body != "Second" {
+		t.Fatalf("got %+v, want the included comment with lid second", out.Comments[1])
+	}
+}
+
 func unmarshalSamplePayload() (*Blog, error) {
 	in := samplePayload()
 	out := new(Blog)
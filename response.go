@@ -3,7 +3,6 @@ package jsonapi
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"reflect"
 	"strconv"
@@ -75,6 +74,17 @@ func MarshalPayload(w io.Writer, models interface{}) error {
 // and doesn't write out results. Useful if you use your own JSON rendering
 // library.
 func Marshal(models interface{}) (Payloader, error) {
+	return MarshalWithOptions(models)
+}
+
+// MarshalWithOptions does the same as Marshal, with options controlling how
+// related records are included, see WithConflictPolicy.
+func MarshalWithOptions(models interface{}, opts ...MarshalOption) (Payloader, error) {
+	c := &marshalConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
 	switch vals := reflect.ValueOf(models); vals.Kind() {
 	case reflect.Slice:
 		m, err := convertToSliceInterface(&models)
@@ -82,7 +92,7 @@ func Marshal(models interface{}) (Payloader, error) {
 			return nil, err
 		}
 
-		payload, err := marshalMany(m)
+		payload, err := marshalMany(m, c.included())
 		if err != nil {
 			return nil, err
 		}
@@ -105,7 +115,7 @@ func Marshal(models interface{}) (Payloader, error) {
 		if reflect.Indirect(vals).Kind() != reflect.Struct {
 			return nil, ErrUnexpectedType
 		}
-		return marshalOne(models)
+		return marshalOne(models, c.included())
 	default:
 		return nil, ErrUnexpectedType
 	}
@@ -131,16 +141,15 @@ func MarshalPayloadWithoutIncluded(w io.Writer, model interface{}) error {
 // marshalOne does the same as MarshalOnePayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func marshalOne(model interface{}) (*OnePayload, error) {
-	included := make(map[string]*Node)
+func marshalOne(model interface{}, included *includedNodes) (*OnePayload, error) {
 
-	rootNode, err := visitModelNode(model, &included, true)
+	rootNode, err := visitModelNode(model, included, true)
 	if err != nil {
 		return nil, err
 	}
 	payload := &OnePayload{Data: rootNode}
 
-	payload.Included = nodeMapValues(&included)
+	payload.Included = included.values()
 
 	return payload, nil
 }
@@ -148,20 +157,19 @@ func marshalOne(model interface{}) (*OnePayload, error) {
 // marshalMany does the same as MarshalManyPayload except it just returns the
 // payload and doesn't write out results. Useful is you use your JSON rendering
 // library.
-func marshalMany(models []interface{}) (*ManyPayload, error) {
+func marshalMany(models []interface{}, included *includedNodes) (*ManyPayload, error) {
 	payload := &ManyPayload{
 		Data: []*Node{},
 	}
-	included := map[string]*Node{}
 
 	for _, model := range models {
-		node, err := visitModelNode(model, &included, true)
+		node, err := visitModelNode(model, included, true)
 		if err != nil {
 			return nil, err
 		}
 		payload.Data = append(payload.Data, node)
 	}
-	payload.Included = nodeMapValues(&included)
+	payload.Included = included.values()
 
 	return payload, nil
 }
@@ -192,16 +200,25 @@ func MarshalOnePayloadEmbedded(w io.Writer, model interface{}) error {
 	return json.NewEncoder(w).Encode(payload)
 }
 
-func visitModelNode(model interface{}, included *map[string]*Node,
+func visitModelNode(model interface{}, included *includedNodes,
 	sideload bool) (*Node, error) {
 	node := new(Node)
 
 	var er error
+	var zeroID bool
 	value := reflect.ValueOf(model)
 	if value.IsNil() {
 		return nil, nil
 	}
 
+	if marshaler, ok := model.(Marshaler); ok {
+		node, err := marshaler.MarshalJSONAPI(&MarshalContext{model: model, included: included, sideload: sideload})
+		if err != nil {
+			return nil, err
+		}
+		return marshalLinksAndMeta(model, node)
+	}
+
 	modelValue := value.Elem()
 	modelType := value.Type().Elem()
 
@@ -224,8 +241,8 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 
 		annotation := args[0]
 
-		if (annotation == annotationClientID && len(args) != 1) ||
-			(annotation != annotationClientID && len(args) < 2) {
+		if ((annotation == annotationClientID || annotation == annotationLID) && len(args) != 1) ||
+			(annotation != annotationClientID && annotation != annotationLID && len(args) < 2) {
 			er = ErrBadJSONAPIStructTag
 			break
 		}
@@ -276,12 +293,18 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				break
 			}
 
+			zeroID = v.IsZero()
 			node.Type = args[1]
 		} else if annotation == annotationClientID {
 			clientID := fieldValue.String()
 			if clientID != "" {
 				node.ClientID = clientID
 			}
+		} else if annotation == annotationLID {
+			lid := fieldValue.String()
+			if lid != "" {
+				node.LID = lid
+			}
 		} else if annotation == annotationAttribute {
 			var omitEmpty, iso8601, rfc3339 bool
 
@@ -370,84 +393,24 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 				continue
 			}
 
-			if node.Relationships == nil {
-				node.Relationships = make(map[string]interface{})
-			}
-
-			var relLinks *Links
-			if linkableModel, ok := model.(RelationshipLinkable); ok {
-				relLinks = linkableModel.JSONAPIRelationshipLinks(args[1])
-			}
-
-			var relMeta *Meta
-			if metableModel, ok := model.(RelationshipMetable); ok {
-				relMeta = metableModel.JSONAPIRelationshipMeta(args[1])
-			}
-
+			c := &MarshalContext{model: model, included: included, sideload: sideload}
 			if isSlice {
 				// to-many relationship
-				relationship, err := visitModelNodeRelationships(
-					fieldValue,
-					included,
-					sideload,
-				)
-				if err != nil {
-					er = err
-					break
-				}
-				relationship.Links = relLinks
-				relationship.Meta = relMeta
-
-				if sideload {
-					shallowNodes := []*Node{}
-					for _, n := range relationship.Data {
-						appendIncluded(included, n)
-						shallowNodes = append(shallowNodes, toShallowNode(n))
-					}
-
-					node.Relationships[args[1]] = &RelationshipManyNode{
-						Data:  shallowNodes,
-						Links: relationship.Links,
-						Meta:  relationship.Meta,
-					}
-				} else {
-					node.Relationships[args[1]] = relationship
+				related := make([]interface{}, fieldValue.Len())
+				for j := range related {
+					related[j] = fieldValue.Index(j).Interface()
 				}
+				er = c.ToMany(node, args[1], related)
+			} else if fieldValue.IsNil() {
+				// Handle null relationship case
+				er = c.ToOne(node, args[1], nil)
 			} else {
 				// to-one relationships
-
-				// Handle null relationship case
-				if fieldValue.IsNil() {
-					node.Relationships[args[1]] = &RelationshipOneNode{Data: nil}
-					continue
-				}
-
-				relationship, err := visitModelNode(
-					fieldValue.Interface(),
-					included,
-					sideload,
-				)
-				if err != nil {
-					er = err
-					break
-				}
-
-				if sideload {
-					appendIncluded(included, relationship)
-					node.Relationships[args[1]] = &RelationshipOneNode{
-						Data:  toShallowNode(relationship),
-						Links: relLinks,
-						Meta:  relMeta,
-					}
-				} else {
-					node.Relationships[args[1]] = &RelationshipOneNode{
-						Data:  relationship,
-						Links: relLinks,
-						Meta:  relMeta,
-					}
-				}
+				er = c.ToOne(node, args[1], fieldValue.Interface())
+			}
+			if er != nil {
+				break
 			}
-
 		} else {
 			er = ErrBadJSONAPIStructTag
 			break
@@ -458,6 +421,17 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 		return nil, er
 	}
 
+	// A resource identified by its local identifier has no id yet
+	if node.LID != "" && zeroID {
+		node.ID = ""
+	}
+
+	return marshalLinksAndMeta(model, node)
+}
+
+// marshalLinksAndMeta sets the links and meta of the resource object of
+// model, if it implements Linkable or Metable.
+func marshalLinksAndMeta(model interface{}, node *Node) (*Node, error) {
 	if linkableModel, isLinkable := model.(Linkable); isLinkable {
 		jl := linkableModel.JSONAPILinks()
 		if er := jl.validate(); er != nil {
@@ -476,55 +450,11 @@ func visitModelNode(model interface{}, included *map[string]*Node,
 func toShallowNode(node *Node) *Node {
 	return &Node{
 		ID:   node.ID,
+		LID:  node.LID,
 		Type: node.Type,
 	}
 }
 
-func visitModelNodeRelationships(models reflect.Value, included *map[string]*Node,
-	sideload bool) (*RelationshipManyNode, error) {
-	nodes := []*Node{}
-
-	for i := 0; i < models.Len(); i++ {
-		n := models.Index(i).Interface()
-
-		node, err := visitModelNode(n, included, sideload)
-		if err != nil {
-			return nil, err
-		}
-
-		nodes = append(nodes, node)
-	}
-
-	return &RelationshipManyNode{Data: nodes}, nil
-}
-
-func appendIncluded(m *map[string]*Node, nodes ...*Node) {
-	included := *m
-
-	for _, n := range nodes {
-		k := fmt.Sprintf("%s,%s", n.Type, n.ID)
-
-		if _, hasNode := included[k]; hasNode {
-			continue
-		}
-
-		included[k] = n
-	}
-}
-
-func nodeMapValues(m *map[string]*Node) []*Node {
-	mp := *m
-	nodes := make([]*Node, len(mp))
-
-	i := 0
-	for _, n := range mp {
-		nodes[i] = n
-		i++
-	}
-
-	return nodes
-}
-
 func convertToSliceInterface(i *interface{}) ([]interface{}, error) {
 	vals := reflect.ValueOf(*i)
 	if vals.Kind() != reflect.Slice {
@@ -663,6 +663,25 @@ func TestInvalidLinkable(t *testing.T) {
 	}
 }
 
+type commentWithoutLinks struct {
+	ID   int    `jsonapi:"primary,comments"`
+	Body string `jsonapi:"attr,body"`
+}
+
+func (c *commentWithoutLinks) JSONAPILinks() *Links {
+	return nil
+}
+
+func TestNilLinkable(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, &commentWithoutLinks{ID: 5, Body: "Hello World"}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(out.Bytes(), []byte(`"links"`)) {
+		t.Fatalf("got %s, want no links", out)
+	}
+}
+
 func TestSupportsMetable(t *testing.T) {
 	testModel := &Blog{
 		ID:        5,
@@ -793,6 +812,39 @@ func TestMarshalPayloadWithoutIncluded(t *testing.T) {
 	}
 }
 
+func TestMarshalPayload_withLIDs(t *testing.T) {
+	data := &Post{
+		LID:   "post",
+		Title: "New",
+		Comments: []*Comment{
+			{LID: "first", Body: "First"},
+			{LID: "second", Body: "Second"},
+		},
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, data); err != nil {
+		t.Fatal(err)
+	}
+
+	resp := new(OnePayload)
+	if err := json.NewDecoder(out).Decode(resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Data.ID != "" || resp.Data.LID != "post" {
+		t.Fatalf("got id %q and lid %q, want only the lid", resp.Data.ID, resp.Data.LID)
+	}
+	if len(resp.Included) != 2 {
+		t.Fatalf("got %d included resources, want one per lid", len(resp.Included))
+	}
+
+	comments := resp.Data.Relationships["comments"].(map[string]interface{})["data"].([]interface{})
+	if lid := comments[1].(map[string]interface{})["lid"]; lid != "second" {
+		t.Fatalf("got linkage lid %v, want second", lid)
+	}
+}
+
 func TestMarshalPayload_many(t *testing.T) {
 	data := []interface{}{
 		&Blog{
@@ -4,6 +4,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"net/http"
 	"reflect"
 	"time"
 )
@@ -92,6 +93,13 @@ func (r *Runtime) MarshalPayload(w io.Writer, model interface{}) error {
 	})
 }
 
+// WriteResource has docs in write.go for WriteResource.
+func (r *Runtime) WriteResource(w http.ResponseWriter, status int, models interface{}, opts ...WriteOption) error {
+	return r.instrumentCall(MarshalStart, MarshalStop, func() error {
+		return WriteResource(w, status, models, opts...)
+	})
+}
+
 func (r *Runtime) instrumentCall(start Event, stop Event, c func() error) error {
 	if !r.shouldInstrument() {
 		return c()
@@ -0,0 +1,368 @@
+package jsonapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaDialect is the JSON Schema dialect of the schemas generated by
+// ResourceSchema and DocumentSchema.
+const SchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema, with the keywords used to describe JSON API
+// documents.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Const                interface{}        `json:"const,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Defs                 map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// SchemaOption configures ResourceSchema and DocumentSchema.
+type SchemaOption func(*schemaConfig)
+
+type schemaConfig struct {
+	request bool
+}
+
+// AsRequest describes resources as sent in creation and update requests,
+// rather than as written by Marshal: the id, attributes and relationships
+// are optional.
+func AsRequest() SchemaOption {
+	return func(c *schemaConfig) {
+		c.request = true
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// ResourceSchema returns a JSON Schema describing the resource objects of a
+// model: the constant type, the id, the attributes with the JSON types of
+// their fields, dates as integers or, with the iso8601 and rfc3339 options,
+// date-time strings, and the shape of the linkage of the relationships.
+//
+// model interface{} should be a struct, a struct pointer, a slice of struct
+// pointers or the reflect.Type of one of those. It returns the errors of
+// ValidateModel for models with malformed tags.
+//
+// http://jsonapi.org/format/#document-resource-objects
+func ResourceSchema(model interface{}, opts ...SchemaOption) (*Schema, error) {
+	t, err := modelType(model)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateModel(t); err != nil {
+		return nil, err
+	}
+
+	c := &schemaConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	s := resourceSchema(t, c)
+	s.Schema = SchemaDialect
+	return s, nil
+}
+
+// DocumentSchema returns a JSON Schema describing the documents holding a
+// model, or a collection of models when model is a slice, as primary data;
+// the resource object is defined in $defs under its resource type.
+//
+// http://jsonapi.org/format/#document-top-level
+func DocumentSchema(model interface{}, opts ...SchemaOption) (*Schema, error) {
+	resource, err := ResourceSchema(model, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resource.Schema = ""
+
+	name := resource.Title
+	ref := &Schema{Ref: "#/$defs/" + name}
+	data := ref
+	if isCollection(model) {
+		data = &Schema{Type: "array", Items: ref}
+	}
+
+	return &Schema{
+		Schema: SchemaDialect,
+		Title:  name,
+		Type:   "object",
+		Properties: map[string]*Schema{
+			memberData:     data,
+			memberIncluded: {Type: "array", Items: &Schema{Type: "object"}},
+			"links":        {Type: "object"},
+			"meta":         {Type: "object"},
+			"jsonapi":      {Type: "object"},
+		},
+		Required: []string{memberData},
+		Defs:     map[string]*Schema{name: resource},
+	}, nil
+}
+
+// isCollection reports whether model, or the type it is, is a slice.
+func isCollection(model interface{}) bool {
+	t, ok := model.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(model)
+	}
+	return derefType(t).Kind() == reflect.Slice
+}
+
+func resourceSchema(t reflect.Type, c *schemaConfig) *Schema {
+	fields, _ := modelFields(t)
+
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+		Required:             []string{"type"},
+	}
+	attributes := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+	relationships := &Schema{Type: "object", Properties: map[string]*Schema{}, AdditionalProperties: false}
+
+	for _, f := range fields {
+		switch f.Annotation {
+		case annotationPrimary:
+			s.Title = f.Key
+			s.Properties["type"] = &Schema{Type: "string", Const: f.Key}
+			s.Properties["id"] = &Schema{Type: "string"}
+		case annotationLID:
+			s.Properties["lid"] = &Schema{Type: "string"}
+		case annotationClientID:
+			s.Properties["client-id"] = &Schema{Type: "string"}
+		case annotationAttribute:
+			attributes.Properties[f.Key] = attributeSchema(f)
+			if !c.request && !f.hasOption(annotationOmitEmpty) && derefType(f.Type) != timeType {
+				attributes.Required = append(attributes.Required, f.Key)
+			}
+		case annotationRelation:
+			relationships.Properties[f.Key] = relationshipSchema(f)
+			if !c.request && !f.hasOption(annotationOmitEmpty) {
+				relationships.Required = append(relationships.Required, f.Key)
+			}
+		}
+	}
+
+	// Marshal omits the zero id of resources with a local identifier
+	switch {
+	case c.request:
+	case s.Properties["lid"] != nil:
+		s.AnyOf = []*Schema{{Required: []string{"id"}}, {Required: []string{"lid"}}}
+	default:
+		s.Required = append(s.Required, "id")
+	}
+
+	if len(attributes.Properties) > 0 {
+		s.Properties["attributes"] = attributes
+	}
+	if len(relationships.Properties) > 0 {
+		s.Properties["relationships"] = relationships
+	}
+	s.Properties["links"] = &Schema{Type: "object"}
+	s.Properties["meta"] = &Schema{Type: "object"}
+
+	return s
+}
+
+// attributeSchema describes the value Marshal writes for an attribute.
+func attributeSchema(f *structField) *Schema {
+	if derefType(f.Type) == timeType {
+		s := &Schema{Type: "integer"}
+		if f.hasOption(annotationISO8601) || f.hasOption(annotationRFC3339) {
+			s = &Schema{Type: "string", Format: "date-time"}
+		}
+		return nullable(s, f.Type)
+	}
+	return valueSchema(f.Type, map[reflect.Type]bool{})
+}
+
+// relationshipSchema describes a relationship object and its linkage.
+func relationshipSchema(f *structField) *Schema {
+	related := f.relatedType()
+	identifier := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"type": {Type: "string"},
+			"id":   {Type: "string"},
+			"meta": {Type: "object"},
+		},
+		Required:             []string{"type", "id"},
+		AdditionalProperties: false,
+	}
+	if name, err := ResourceType(related); err == nil {
+		identifier.Title = name
+		identifier.Properties["type"].Const = name
+	}
+	if field, _ := lookupField(related, annotationLID, ""); field != nil {
+		identifier.Properties["lid"] = &Schema{Type: "string"}
+		identifier.Required = []string{"type"}
+		identifier.AnyOf = []*Schema{{Required: []string{"id"}}, {Required: []string{"lid"}}}
+	}
+
+	data := &Schema{OneOf: []*Schema{identifier, {Type: "null"}}}
+	if f.Type.Kind() == reflect.Slice {
+		data = &Schema{Type: "array", Items: identifier}
+	}
+
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			memberData: data,
+			"links":    {Type: "object"},
+			"meta":     {Type: "object"},
+		},
+		AdditionalProperties: false,
+	}
+}
+
+// ValueSchema returns a JSON Schema describing the JSON encoding of v, as
+// written by encoding/json, e.g. of an ErrorObject.
+func ValueSchema(v interface{}) *Schema {
+	t, ok := v.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(v)
+	}
+	if t == nil {
+		return &Schema{}
+	}
+	return valueSchema(t, map[reflect.Type]bool{})
+}
+
+// valueSchema describes the JSON encoding of a Go value of type t, as
+// written by encoding/json.
+func valueSchema(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	if t.Kind() == reflect.Ptr {
+		return nullable(valueSchema(t.Elem(), visiting), t)
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}
+	}
+	if t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return nullable(&Schema{Type: "string", ContentEncoding: "base64"}, t)
+		}
+		s := &Schema{Type: "array", Items: valueSchema(t.Elem(), visiting)}
+		if t.Kind() == reflect.Slice {
+			return nullable(s, t)
+		}
+		return s
+	case reflect.Map:
+		return nullable(&Schema{Type: "object", AdditionalProperties: valueSchema(t.Elem(), visiting)}, t)
+	case reflect.Struct:
+		if visiting[t] {
+			return &Schema{Type: "object"}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+		return structSchema(t, visiting)
+	}
+
+	return &Schema{}
+}
+
+// structSchema describes a struct encoded by encoding/json, following its
+// rules for exported fields and json tags.
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+
+		name := sf.Name
+		omitEmpty := false
+		if tag, ok := sf.Tag.Lookup("json"); ok {
+			args := strings.Split(tag, ",")
+			if tag == "-" {
+				continue
+			}
+			if args[0] != "" {
+				name = args[0]
+			}
+			for _, arg := range args[1:] {
+				omitEmpty = omitEmpty || arg == "omitempty"
+			}
+		}
+
+		// Embedded structs without a name are flattened into their parent
+		if sf.Anonymous && name == sf.Name && derefType(sf.Type).Kind() == reflect.Struct {
+			embedded := valueSchema(derefType(sf.Type), visiting)
+			for k, v := range embedded.Properties {
+				s.Properties[k] = v
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		s.Properties[name] = valueSchema(sf.Type, visiting)
+		if !omitEmpty {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s
+}
+
+// nullable allows null for the schema of a pointer, slice or map type t,
+// whose nil value is encoded as null.
+func nullable(s *Schema, t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+	default:
+		return s
+	}
+
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+	case []string:
+		if typ[len(typ)-1] != "null" {
+			s.Type = append(typ, "null")
+		}
+	}
+	return s
+}
@@ -0,0 +1,200 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func schemaJSON(t *testing.T, s *Schema) string {
+	t.Helper()
+	out, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestResourceSchema(t *testing.T) {
+	s, err := ResourceSchema(new(Book))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.Schema != SchemaDialect || s.Title != "books" {
+		t.Fatalf("got dialect %q and title %q", s.Schema, s.Title)
+	}
+	if got := schemaJSON(t, s.Properties["type"]); got != `{"type":"string","const":"books"}` {
+		t.Fatalf("got type %s", got)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"type", "id"}) {
+		t.Fatalf("got required %v", s.Required)
+	}
+
+	want := `{"type":"object","properties":{` +
+		`"author":{"type":"string"},` +
+		`"description":{"type":["string","null"]},` +
+		`"isbn":{"type":"string"},` +
+		`"pages":{"type":["integer","null"],"minimum":0},` +
+		`"tags":{"type":["array","null"],"items":{"type":"string"}},` +
+		`"title":{"type":"string"}},` +
+		`"required":["author","isbn","description","tags"],"additionalProperties":false}`
+	if got := schemaJSON(t, s.Properties["attributes"]); got != want {
+		t.Fatalf("got attributes\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResourceSchema_dates(t *testing.T) {
+	s, err := ResourceSchema(TimestampModel{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"type":"object","properties":{` +
+		`"defaultp":{"type":["integer","null"]},` +
+		`"defaultv":{"type":"integer"},` +
+		`"iso8601p":{"type":["string","null"],"format":"date-time"},` +
+		`"iso8601v":{"type":"string","format":"date-time"},` +
+		`"rfc3339p":{"type":["string","null"],"format":"date-time"},` +
+		`"rfc3339v":{"type":"string","format":"date-time"}},` +
+		`"additionalProperties":false}`
+	if got := schemaJSON(t, s.Properties["attributes"]); got != want {
+		t.Fatalf("got attributes\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResourceSchema_nestedAttributes(t *testing.T) {
+	s, err := ResourceSchema(new(Company))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teams := s.Properties["attributes"].Properties["teams"]
+	want := `{"type":["array","null"],"items":{"type":"object","properties":{` +
+		`"Leader":{"type":["object","null"],"properties":{"Age":{"type":"integer"},"Firstname":{"type":"string"},` +
+		`"HiredAt":{"type":["string","null"],"format":"date-time"},"Surname":{"type":"string"}},` +
+		`"required":["Firstname","Surname","Age","HiredAt"]},` +
+		`"Members":{"type":["array","null"],"items":{"type":"object","properties":{"Age":{"type":"integer"},"Firstname":{"type":"string"},` +
+		`"HiredAt":{"type":["string","null"],"format":"date-time"},"Surname":{"type":"string"}},` +
+		`"required":["Firstname","Surname","Age","HiredAt"]}},` +
+		`"Name":{"type":"string"}},"required":["Name","Leader","Members"]}}`
+	if got := schemaJSON(t, teams); got != want {
+		t.Fatalf("got teams\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestResourceSchema_relationships(t *testing.T) {
+	s, err := ResourceSchema(new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+	relationships := s.Properties["relationships"]
+	if !reflect.DeepEqual(relationships.Required, []string{"posts", "current_post"}) {
+		t.Fatalf("got required relationships %v", relationships.Required)
+	}
+
+	identifier := `{"title":"posts","type":"object","properties":{` +
+		`"id":{"type":"string"},"lid":{"type":"string"},"meta":{"type":"object"},"type":{"type":"string","const":"posts"}},` +
+		`"required":["type"],"additionalProperties":false,"anyOf":[{"required":["id"]},{"required":["lid"]}]}`
+	for name, want := range map[string]string{
+		"posts":        `{"type":"array","items":` + identifier + `}`,
+		"current_post": `{"oneOf":[` + identifier + `,{"type":"null"}]}`,
+	} {
+		if got := schemaJSON(t, relationships.Properties[name].Properties[memberData]); got != want {
+			t.Errorf("got %s linkage\n%s\nwant\n%s", name, got, want)
+		}
+	}
+}
+
+func TestResourceSchema_asRequest(t *testing.T) {
+	s, err := ResourceSchema(new(Blog), AsRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(s.Required, []string{"type"}) || s.Properties["attributes"].Required != nil ||
+		s.Properties["relationships"].Required != nil {
+		t.Fatalf("got %s, want only the type required", schemaJSON(t, s))
+	}
+	if s.Properties["client-id"] == nil {
+		t.Fatal("want the client-id member")
+	}
+}
+
+func TestResourceSchema_invalidModel(t *testing.T) {
+	if _, err := ResourceSchema(new(BadModel)); err == nil {
+		t.Fatal("want an error for a model with a malformed tag")
+	}
+}
+
+func TestResourceSchema_describesMarshalledResources(t *testing.T) {
+	s, err := ResourceSchema(new(Blog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBuffer(nil)
+	if err := MarshalPayload(out, testIncludedBlog()); err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Data map[string]json.RawMessage
+	}
+	if err := json.Unmarshal(out.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, member := range []string{"attributes", "relationships"} {
+		values := map[string]interface{}{}
+		if err := json.Unmarshal(document.Data[member], &values); err != nil {
+			t.Fatal(err)
+		}
+
+		object := s.Properties[member]
+		for _, name := range object.Required {
+			if _, ok := values[name]; !ok {
+				t.Errorf("required %s %q is missing from %s", member, name, out)
+			}
+		}
+		for name := range values {
+			if object.Properties[name] == nil {
+				t.Errorf("%s %q is not described by the schema", member, name)
+			}
+		}
+	}
+}
+
+func TestDocumentSchema(t *testing.T) {
+	s, err := DocumentSchema([]*Blog{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schemaJSON(t, s.Properties[memberData]); got != `{"type":"array","items":{"$ref":"#/$defs/blogs"}}` {
+		t.Fatalf("got data %s", got)
+	}
+	if resource := s.Defs["blogs"]; resource == nil || resource.Schema != "" {
+		t.Fatalf("got $defs %v, want the blogs resource without $schema", s.Defs)
+	}
+
+	s, err = DocumentSchema(reflect.TypeOf(new(Blog)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := schemaJSON(t, s.Properties[memberData]); got != `{"$ref":"#/$defs/blogs"}` {
+		t.Fatalf("got data %s", got)
+	}
+}
+
+func TestValueSchema(t *testing.T) {
+	want := `{"type":["object","null"],"properties":{` +
+		`"code":{"type":"string"},"detail":{"type":"string"},"id":{"type":"string"},` +
+		`"meta":{"type":["object","null"],"additionalProperties":{}},` +
+		`"source":{"type":["object","null"],"properties":{"header":{"type":"string"},"parameter":{"type":"string"},"pointer":{"type":"string"}}},` +
+		`"status":{"type":"string"},"title":{"type":"string"}}}`
+	if got := schemaJSON(t, ValueSchema(new(ErrorObject))); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+	if got := schemaJSON(t, ValueSchema(nil)); got != `{}` {
+		t.Fatalf("got %s, want the empty schema", got)
+	}
+}
@@ -0,0 +1,395 @@
+// Package server serves JSON API resource endpoints for models annotated with
+// jsonapi struct tags.
+//
+// A Server routes the collection (/{type}), individual resource
+// (/{type}/{id}) and relationship (/{type}/{id}/relationships/{name})
+// endpoints of every registered model to its Repository and
+// takes care of content negotiation, conditional requests with ETags (see
+// jsonapi.Conditional), request document checks, status codes and error
+// documents as laid out in http://jsonapi.org/format/#crud.
+//
+//	s := server.New("/api")
+//	if err := s.Register(new(Blog), blogRepository); err != nil {
+//		log.Fatal(err)
+//	}
+//	http.Handle("/api/", s)
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonapi"
+)
+
+var (
+	// ErrNotFound is returned by a Repository when the requested resource does
+	// not exist; the Server responds with 404 Not Found.
+	ErrNotFound = errors.New("resource not found")
+	// ErrConflict is returned by a Repository when a change can't be applied,
+	// e.g. a client-generated ID already exists; the Server responds with 409
+	// Conflict.
+	ErrConflict = errors.New("resource conflict")
+)
+
+// Repository is implemented by the storage of a resource type. Errors
+// returned may be ErrNotFound, ErrConflict or a *jsonapi.ErrorObject to
+// control the response; any other error results in 500 Internal Server Error.
+type Repository interface {
+	// FindAll returns the resources of the collection as a slice of struct
+	// pointers. The request is given to allow filtering and pagination.
+	FindAll(r *http.Request) (interface{}, error)
+	// FindOne returns the resource with the given id as a struct pointer.
+	FindOne(r *http.Request, id string) (interface{}, error)
+	// Create persists model, a struct pointer, and assigns its ID unless it
+	// was generated by the client.
+	Create(r *http.Request, model interface{}) error
+	// Update persists model, a struct pointer previously returned by FindOne
+	// with the changes of the request applied. To prevent lost updates from
+	// concurrent requests to the resource endpoint, it may check their
+	// If-Match precondition with jsonapi.CheckIfMatch against the stored
+	// resource, within the transaction updating it, and return
+	// jsonapi.ErrPreconditionFailed.
+	Update(r *http.Request, model interface{}) error
+	// Delete removes the resource with the given id.
+	Delete(r *http.Request, id string) error
+}
+
+// Server is an http.Handler serving the endpoints of registered models.
+type Server struct {
+	prefix    string
+	resources map[string]*resource
+	handler   http.Handler
+}
+
+type resource struct {
+	name  string
+	model reflect.Type
+	repo  Repository
+}
+
+// New creates a Server for URLs below prefix, e.g. "/api"; the prefix is
+// also used to build the Location of created resources.
+func New(prefix string) *Server {
+	s := &Server{
+		prefix:    strings.TrimSuffix(prefix, "/"),
+		resources: map[string]*resource{},
+	}
+	s.handler = jsonapi.NegotiateContent(jsonapi.Conditional(http.HandlerFunc(s.route)))
+
+	return s
+}
+
+// Register serves the resource type of model, a struct pointer with jsonapi
+// tags, from repo.
+func (s *Server) Register(model interface{}, repo Repository) error {
+	if err := jsonapi.ValidateModel(model); err != nil {
+		return err
+	}
+	name, err := jsonapi.ResourceType(model)
+	if err != nil {
+		return err
+	}
+	if _, exists := s.resources[name]; exists {
+		return fmt.Errorf("server: resource type %q is already registered", name)
+	}
+
+	t := reflect.TypeOf(model)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s.resources[name] = &resource{name: name, model: t, repo: repo}
+
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, s.prefix), "/")
+	segments := strings.Split(path, "/")
+
+	res, ok := s.resources[segments[0]]
+	if !ok {
+		writeStatusError(w, http.StatusNotFound, "No such resource type")
+		return
+	}
+
+	switch len(segments) {
+	case 1:
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r, res)
+		case http.MethodPost:
+			s.create(w, r, res)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case 2:
+		id := segments[1]
+		switch r.Method {
+		case http.MethodGet:
+			s.show(w, r, res, id)
+		case http.MethodPatch:
+			s.update(w, r, res, id)
+		case http.MethodDelete:
+			s.delete(w, r, res, id)
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodDelete)
+		}
+	case 4:
+		if segments[2] != "relationships" {
+			writeStatusError(w, http.StatusNotFound, "No such endpoint")
+			return
+		}
+		s.relationship(w, r, res, segments[1], segments[3])
+	default:
+		writeStatusError(w, http.StatusNotFound, "No such endpoint")
+	}
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, res *resource) {
+	if errs := jsonapi.ValidateQuery(res.model, r.URL.Query()); errs != nil {
+		jsonapi.WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	models, err := res.repo.FindAll(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	jsonapi.WriteResource(w, http.StatusOK, models)
+}
+
+func (s *Server) show(w http.ResponseWriter, r *http.Request, res *resource, id string) {
+	if errs := jsonapi.ValidateQuery(res.model, r.URL.Query()); errs != nil {
+		jsonapi.WriteErrors(w, http.StatusBadRequest, errs)
+		return
+	}
+
+	model, err := s.find(r, res, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	jsonapi.WriteResource(w, http.StatusOK, model)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, res *resource) {
+	body, err := readDocument(r, res, "")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	model := reflect.New(res.model).Interface()
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(body), model); err != nil {
+		writeStatusError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := res.repo.Create(r, model); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	payload, err := jsonapi.Marshal(model)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	location := fmt.Sprintf("%s/%s/%s", s.prefix, res.name, payload.(*jsonapi.OnePayload).Data.ID)
+
+	jsonapi.WritePayload(w, http.StatusCreated, payload, jsonapi.WithLocation(location))
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, res *resource, id string) {
+	body, err := readDocument(r, res, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	model, err := s.find(r, res, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// Members absent from the document keep their current values
+	if err := jsonapi.UnmarshalPayload(bytes.NewReader(body), model); err != nil {
+		writeStatusError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := res.repo.Update(r, model); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	jsonapi.WriteResource(w, http.StatusOK, model)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, res *resource, id string) {
+	if err := res.repo.Delete(r, id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	jsonapi.WriteNoContent(w)
+}
+
+// relationship serves /{type}/{id}/relationships/{name}; updates replace,
+// add or remove members of the relationship and are persisted through the
+// Repository's Update.
+func (s *Server) relationship(w http.ResponseWriter, r *http.Request, res *resource, id, name string) {
+	var update func(io.Reader, interface{}, string) error
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPatch:
+		update = jsonapi.UnmarshalRelationship
+	case http.MethodPost:
+		update = jsonapi.AddRelationshipMembers
+	case http.MethodDelete:
+		update = jsonapi.RemoveRelationshipMembers
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPatch, http.MethodPost, http.MethodDelete)
+		return
+	}
+
+	model, err := s.find(r, res, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if update == nil {
+		payload, err := jsonapi.MarshalRelationship(model, name)
+		if err != nil {
+			writeRelationshipError(w, err, http.StatusInternalServerError)
+			return
+		}
+		jsonapi.WritePayload(w, http.StatusOK, payload)
+		return
+	}
+
+	if err := update(r.Body, model, name); err != nil {
+		writeRelationshipError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := res.repo.Update(r, model); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	jsonapi.WriteNoContent(w)
+}
+
+func (s *Server) find(r *http.Request, res *resource, id string) (interface{}, error) {
+	model, err := res.repo.FindOne(r, id)
+	if err != nil {
+		return nil, err
+	}
+	if v := reflect.ValueOf(model); !v.IsValid() || v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, ErrNotFound
+	}
+
+	return model, nil
+}
+
+// readDocument reads the request body and checks that its primary data is a
+// resource object of the expected type and, if given, id.
+func readDocument(r *http.Request, res *resource, id string) ([]byte, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, statusError(http.StatusBadRequest, err.Error())
+	}
+
+	payload := new(jsonapi.OnePayload)
+	if err := json.Unmarshal(body, payload); err != nil {
+		return nil, statusError(http.StatusBadRequest, err.Error())
+	}
+	if payload.Data == nil {
+		return nil, statusError(http.StatusBadRequest,
+			"The request document must contain a resource object as primary data")
+	}
+	if payload.Data.Type != res.name {
+		return nil, statusError(http.StatusConflict, fmt.Sprintf(
+			"The resource object's type %q does not match the endpoint's type %q",
+			payload.Data.Type, res.name))
+	}
+	if id != "" && payload.Data.ID != id {
+		return nil, statusError(http.StatusConflict, fmt.Sprintf(
+			"The resource object's id %q does not match the endpoint's id %q",
+			payload.Data.ID, id))
+	}
+
+	return body, nil
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var e *jsonapi.ErrorObject
+	switch {
+	case errors.As(err, &e):
+		jsonapi.WriteErrors(w, 0, []*jsonapi.ErrorObject{e})
+	case errors.Is(err, ErrNotFound):
+		writeStatusError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrConflict):
+		writeStatusError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, jsonapi.ErrPreconditionFailed):
+		e := statusError(http.StatusPreconditionFailed, err.Error())
+		e.Source = &jsonapi.ErrorSource{Header: "If-Match"}
+		jsonapi.WriteErrors(w, http.StatusPreconditionFailed, []*jsonapi.ErrorObject{e})
+	default:
+		writeStatusError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// writeRelationshipError writes the errors of the jsonapi relationship
+// functions, or status for any other error.
+func writeRelationshipError(w http.ResponseWriter, err error, status int) {
+	switch err {
+	case jsonapi.ErrUnknownRelationship:
+		status = http.StatusNotFound
+	case jsonapi.ErrNotToManyRelationship:
+		status = http.StatusForbidden
+	case jsonapi.ErrLinkageTypeMismatch:
+		status = http.StatusConflict
+	case jsonapi.ErrInvalidLinkage:
+		status = http.StatusBadRequest
+	}
+
+	writeStatusError(w, status, err.Error())
+}
+
+func writeStatusError(w http.ResponseWriter, status int, detail string) {
+	jsonapi.WriteErrors(w, status, []*jsonapi.ErrorObject{statusError(status, detail)})
+}
+
+func statusError(status int, detail string) *jsonapi.ErrorObject {
+	return &jsonapi.ErrorObject{
+		Title:  http.StatusText(status),
+		Detail: detail,
+		Status: strconv.Itoa(status),
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeStatusError(w, http.StatusMethodNotAllowed, "The endpoint does not support this method")
+}
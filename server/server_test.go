@@ -0,0 +1,490 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/jsonapi"
+)
+
+type Article struct {
+	ID     string  `jsonapi:"primary,articles"`
+	Title  string  `jsonapi:"attr,title"`
+	Body   string  `jsonapi:"attr,body"`
+	Author *Author `jsonapi:"relation,author,omitempty"`
+	Tags   []*Tag  `jsonapi:"relation,tags"`
+}
+
+type Author struct {
+	ID   string `jsonapi:"primary,authors"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type Tag struct {
+	ID   string `jsonapi:"primary,tags"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type articleRepository struct {
+	articles map[string]*Article
+	nextID   int
+	// beforeUpdate is called by Update before it checks the precondition,
+	// as a concurrent request would
+	beforeUpdate func()
+}
+
+func newArticleRepository() *articleRepository {
+	return &articleRepository{
+		articles: map[string]*Article{
+			"1": {
+				ID:     "1",
+				Title:  "First",
+				Body:   "Hello",
+				Author: &Author{ID: "9", Name: "Jane"},
+				Tags:   []*Tag{{ID: "go", Name: "Go"}},
+			},
+		},
+		nextID: 2,
+	}
+}
+
+func (repo *articleRepository) FindAll(r *http.Request) (interface{}, error) {
+	articles := []*Article{}
+	for _, a := range repo.articles {
+		articles = append(articles, a)
+	}
+	sort.Slice(articles, func(i, j int) bool { return articles[i].ID < articles[j].ID })
+	return articles, nil
+}
+
+func (repo *articleRepository) FindOne(r *http.Request, id string) (interface{}, error) {
+	a, ok := repo.articles[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *a
+	return &copied, nil
+}
+
+func (repo *articleRepository) Create(r *http.Request, model interface{}) error {
+	a := model.(*Article)
+	if a.ID == "" {
+		a.ID = strconv.Itoa(repo.nextID)
+		repo.nextID++
+	}
+	if _, exists := repo.articles[a.ID]; exists {
+		return ErrConflict
+	}
+	if a.Title == "" {
+		return &jsonapi.ErrorObject{Title: "Invalid Attribute", Status: "422"}
+	}
+	repo.articles[a.ID] = a
+	return nil
+}
+
+func (repo *articleRepository) Update(r *http.Request, model interface{}) error {
+	a := model.(*Article)
+	if repo.beforeUpdate != nil {
+		repo.beforeUpdate()
+	}
+	if !strings.Contains(r.URL.Path, "/relationships/") {
+		if err := jsonapi.CheckIfMatch(r, repo.articles[a.ID]); err != nil {
+			return err
+		}
+	}
+	repo.articles[a.ID] = a
+	return nil
+}
+
+func (repo *articleRepository) Delete(r *http.Request, id string) error {
+	if _, ok := repo.articles[id]; !ok {
+		return ErrNotFound
+	}
+	delete(repo.articles, id)
+	return nil
+}
+
+func testServer(t *testing.T) (*Server, *articleRepository) {
+	repo := newArticleRepository()
+	s := New("/api")
+	if err := s.Register(new(Article), repo); err != nil {
+		t.Fatal(err)
+	}
+	return s, repo
+}
+
+func serve(s *Server, method, path, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.Header.Set("Content-Type", jsonapi.MediaType)
+	r.Header.Set("Accept", jsonapi.MediaType)
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, r)
+	return rr
+}
+
+func TestServer_list(t *testing.T) {
+	s, _ := testServer(t)
+	rr := serve(s, http.MethodGet, "/api/articles", "")
+
+	if got, want := rr.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got, want := rr.Header().Get("Content-Type"), jsonapi.MediaType; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+
+	payload := new(jsonapi.ManyPayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 1 || len(payload.Included) != 2 {
+		t.Fatalf("got %d resources and %d included, want 1 and 2", len(payload.Data), len(payload.Included))
+	}
+}
+
+func TestServer_listInvalidInclude(t *testing.T) {
+	s, _ := testServer(t)
+	rr := serve(s, http.MethodGet, "/api/articles?include=comments", "")
+
+	if got, want := rr.Code, http.StatusBadRequest; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_show(t *testing.T) {
+	s, _ := testServer(t)
+
+	rr := serve(s, http.MethodGet, "/api/articles/1", "")
+	if got, want := rr.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+
+	rr = serve(s, http.MethodGet, "/api/articles/2", "")
+	if got, want := rr.Code, http.StatusNotFound; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_create(t *testing.T) {
+	s, repo := testServer(t)
+	rr := serve(s, http.MethodPost, "/api/articles",
+		`{"data": {"type": "articles", "attributes": {"title": "Second"}}}`)
+
+	if got, want := rr.Code, http.StatusCreated; got != want {
+		t.Fatalf("got status %d, want %d: %s", got, want, rr.Body)
+	}
+	if got, want := rr.Header().Get("Location"), "/api/articles/2"; got != want {
+		t.Fatalf("got Location %q, want %q", got, want)
+	}
+	if repo.articles["2"] == nil || repo.articles["2"].Title != "Second" {
+		t.Fatalf("The article was not created")
+	}
+
+	payload := new(jsonapi.OnePayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.ID != "2" {
+		t.Fatalf("got id %q, want 2", payload.Data.ID)
+	}
+}
+
+func TestServer_createErrors(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		body   string
+		status int
+	}{
+		{
+			desc:   "type mismatch",
+			body:   `{"data": {"type": "authors", "attributes": {"name": "Joe"}}}`,
+			status: http.StatusConflict,
+		},
+		{
+			desc:   "existing client-generated id",
+			body:   `{"data": {"type": "articles", "id": "1", "attributes": {"title": "Again"}}}`,
+			status: http.StatusConflict,
+		},
+		{
+			desc:   "repository error object",
+			body:   `{"data": {"type": "articles", "attributes": {"body": "No title"}}}`,
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			desc:   "missing data",
+			body:   `{"meta": {}}`,
+			status: http.StatusBadRequest,
+		},
+		{
+			desc:   "malformed document",
+			body:   `{"data": `,
+			status: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, _ := testServer(t)
+			rr := serve(s, http.MethodPost, "/api/articles", tc.body)
+
+			if got, want := rr.Code, tc.status; got != want {
+				t.Fatalf("got status %d, want %d: %s", got, want, rr.Body)
+			}
+			payload := new(jsonapi.ErrorsPayload)
+			if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+				t.Fatal(err)
+			}
+			if len(payload.Errors) != 1 {
+				t.Fatalf("got %d errors, want 1", len(payload.Errors))
+			}
+		})
+	}
+}
+
+func TestServer_update(t *testing.T) {
+	s, repo := testServer(t)
+	rr := serve(s, http.MethodPatch, "/api/articles/1",
+		`{"data": {"type": "articles", "id": "1", "attributes": {"title": "Updated"}}}`)
+
+	if got, want := rr.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %d, want %d: %s", got, want, rr.Body)
+	}
+	if got, want := repo.articles["1"].Title, "Updated"; got != want {
+		t.Fatalf("got title %q, want %q", got, want)
+	}
+	if got, want := repo.articles["1"].Body, "Hello"; got != want {
+		t.Fatalf("got body %q, want %q", got, want)
+	}
+}
+
+func TestServer_updateErrors(t *testing.T) {
+	s, _ := testServer(t)
+
+	rr := serve(s, http.MethodPatch, "/api/articles/1",
+		`{"data": {"type": "articles", "id": "2", "attributes": {"title": "Updated"}}}`)
+	if got, want := rr.Code, http.StatusConflict; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+
+	rr = serve(s, http.MethodPatch, "/api/articles/7",
+		`{"data": {"type": "articles", "id": "7", "attributes": {"title": "Updated"}}}`)
+	if got, want := rr.Code, http.StatusNotFound; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_delete(t *testing.T) {
+	s, repo := testServer(t)
+
+	rr := serve(s, http.MethodDelete, "/api/articles/1", "")
+	if got, want := rr.Code, http.StatusNoContent; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if _, ok := repo.articles["1"]; ok {
+		t.Fatal("The article was not deleted")
+	}
+
+	rr = serve(s, http.MethodDelete, "/api/articles/1", "")
+	if got, want := rr.Code, http.StatusNotFound; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_routing(t *testing.T) {
+	s, _ := testServer(t)
+
+	rr := serve(s, http.MethodPut, "/api/articles/1", "")
+	if got, want := rr.Code, http.StatusMethodNotAllowed; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got, want := rr.Header().Get("Allow"), "GET, PATCH, DELETE"; got != want {
+		t.Fatalf("got Allow %q, want %q", got, want)
+	}
+
+	rr = serve(s, http.MethodGet, "/api/comments", "")
+	if got, want := rr.Code, http.StatusNotFound; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_register(t *testing.T) {
+	s, repo := testServer(t)
+
+	if err := s.Register(new(Article), repo); err == nil {
+		t.Fatal("Was expecting an error registering a type twice")
+	}
+	if err := s.Register(struct{ ID string }{}, repo); err == nil {
+		t.Fatal("Was expecting an error registering a model without a primary tag")
+	}
+}
+
+func TestServer_showRelationship(t *testing.T) {
+	s, _ := testServer(t)
+
+	rr := serve(s, http.MethodGet, "/api/articles/1/relationships/tags", "")
+	if got, want := rr.Code, http.StatusOK; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	payload := new(jsonapi.RelationshipManyNode)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Data) != 1 || payload.Data[0].ID != "go" {
+		t.Fatalf("got %v, want the go tag", payload.Data)
+	}
+
+	rr = serve(s, http.MethodGet, "/api/articles/1/relationships/comments", "")
+	if got, want := rr.Code, http.StatusNotFound; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+}
+
+func TestServer_updateRelationship(t *testing.T) {
+	for _, tc := range []struct {
+		desc   string
+		method string
+		name   string
+		body   string
+		status int
+		tags   []string
+	}{
+		{
+			desc:   "replace to-many",
+			method: http.MethodPatch,
+			name:   "tags",
+			body:   `{"data": [{"type": "tags", "id": "api"}]}`,
+			status: http.StatusNoContent,
+			tags:   []string{"api"},
+		},
+		{
+			desc:   "add to-many members",
+			method: http.MethodPost,
+			name:   "tags",
+			body:   `{"data": [{"type": "tags", "id": "go"}, {"type": "tags", "id": "api"}]}`,
+			status: http.StatusNoContent,
+			tags:   []string{"go", "api"},
+		},
+		{
+			desc:   "remove to-many members",
+			method: http.MethodDelete,
+			name:   "tags",
+			body:   `{"data": [{"type": "tags", "id": "go"}]}`,
+			status: http.StatusNoContent,
+			tags:   []string{},
+		},
+		{
+			desc:   "clear to-one",
+			method: http.MethodPatch,
+			name:   "author",
+			body:   `{"data": null}`,
+			status: http.StatusNoContent,
+			tags:   []string{"go"},
+		},
+		{
+			desc:   "add to to-one",
+			method: http.MethodPost,
+			name:   "author",
+			body:   `{"data": [{"type": "authors", "id": "1"}]}`,
+			status: http.StatusForbidden,
+			tags:   []string{"go"},
+		},
+		{
+			desc:   "type mismatch",
+			method: http.MethodPatch,
+			name:   "tags",
+			body:   `{"data": [{"type": "authors", "id": "1"}]}`,
+			status: http.StatusConflict,
+			tags:   []string{"go"},
+		},
+		{
+			desc:   "invalid linkage",
+			method: http.MethodPatch,
+			name:   "tags",
+			body:   `{"data": null}`,
+			status: http.StatusBadRequest,
+			tags:   []string{"go"},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			s, repo := testServer(t)
+			rr := serve(s, tc.method, "/api/articles/1/relationships/"+tc.name, tc.body)
+
+			if got, want := rr.Code, tc.status; got != want {
+				t.Fatalf("got status %d, want %d: %s", got, want, rr.Body)
+			}
+			tags := []string{}
+			for _, tag := range repo.articles["1"].Tags {
+				tags = append(tags, tag.ID)
+			}
+			if got, want := strings.Join(tags, ","), strings.Join(tc.tags, ","); got != want {
+				t.Fatalf("got tags %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestServer_conditionalRequests(t *testing.T) {
+	s, repo := testServer(t)
+
+	rr := serve(s, http.MethodGet, "/api/articles/1", "")
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("Expected an ETag")
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/articles/1", nil)
+	r.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, r)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("got status %d, want %d without body", rr.Code, http.StatusNotModified)
+	}
+
+	update := `{"data": {"type": "articles", "id": "1", "attributes": {"title": "Changed"}}}`
+	for _, ifMatch := range []string{`"stale"`, etag} {
+		r = httptest.NewRequest(http.MethodPatch, "/api/articles/1", strings.NewReader(update))
+		r.Header.Set("Content-Type", jsonapi.MediaType)
+		r.Header.Set("If-Match", ifMatch)
+		rr = httptest.NewRecorder()
+		s.ServeHTTP(rr, r)
+
+		if ifMatch == etag {
+			if rr.Code != http.StatusOK || repo.articles["1"].Title != "Changed" {
+				t.Fatalf("got status %d, want the article updated", rr.Code)
+			}
+			if rr.Header().Get("ETag") == etag {
+				t.Fatal("Expected the ETag to change with the article")
+			}
+			continue
+		}
+		if rr.Code != http.StatusPreconditionFailed || repo.articles["1"].Title != "First" {
+			t.Fatalf("got status %d, want %d without the update", rr.Code, http.StatusPreconditionFailed)
+		}
+	}
+}
+
+func TestServer_concurrentUpdate(t *testing.T) {
+	s, repo := testServer(t)
+	etag := serve(s, http.MethodGet, "/api/articles/1", "").Header().Get("ETag")
+
+	// Another update lands after the If-Match check of jsonapi.Conditional
+	repo.beforeUpdate = func() {
+		concurrent := *repo.articles["1"]
+		concurrent.Title = "Concurrent"
+		repo.articles["1"] = &concurrent
+	}
+
+	update := `{"data": {"type": "articles", "id": "1", "attributes": {"title": "Changed"}}}`
+	r := httptest.NewRequest(http.MethodPatch, "/api/articles/1", strings.NewReader(update))
+	r.Header.Set("Content-Type", jsonapi.MediaType)
+	r.Header.Set("If-Match", etag)
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusPreconditionFailed || repo.articles["1"].Title != "Concurrent" {
+		t.Fatalf("got status %d and title %q, want %d without the update", rr.Code, repo.articles["1"].Title, http.StatusPreconditionFailed)
+	}
+}
@@ -0,0 +1,233 @@
+package jsonapi
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ModelError describes a jsonapi struct tag that breaks the rules of the
+// specification or of this package.
+type ModelError struct {
+	// Model is the struct type declaring the field
+	Model reflect.Type
+	// Field is the Go name of the offending field, empty for violations of
+	// the struct as a whole
+	Field string
+	// Reason describes the violation
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *ModelError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("jsonapi: %s: %s", e.Model, e.Reason)
+	}
+	return fmt.Sprintf("jsonapi: %s.%s: %s", e.Model, e.Field, e.Reason)
+}
+
+// ModelErrors is returned by ValidateModel, with a ModelError per violation.
+type ModelErrors []*ModelError
+
+// Error implements the error interface.
+func (errs ModelErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// modelChecks caches the result of validateModelFields per struct type.
+var modelChecks sync.Map
+
+type modelCheck struct {
+	errs    ModelErrors
+	related []reflect.Type
+}
+
+// ValidateModel checks the jsonapi tags of a model, and of the models it is
+// related to:
+//
+//   - the struct has exactly one "primary" field, of a string or integer
+//     type, whose resource type is a legal member name
+//   - "attr" and "relation" names are legal member names, attributes are not
+//     named id, type, relationships or links and relations not id or type
+//   - no two attributes or relations share a name
+//   - "relation" fields are struct pointers or slices of struct pointers
+//   - "client-id" and "lid" fields are strings
+//   - annotations and their options are known
+//
+// The fields of each type are checked once and the result is cached, so it
+// is cheap to call when registering models, e.g. with server.Register. It
+// returns nil or ModelErrors naming the offending Go fields.
+//
+// http://jsonapi.org/format/#document-member-names
+func ValidateModel(model interface{}) error {
+	t, err := modelType(model)
+	if err != nil {
+		return err
+	}
+
+	errs := ModelErrors{}
+	visited := map[reflect.Type]bool{t: true}
+	queue := []reflect.Type{t}
+	for len(queue) > 0 {
+		check := checkModel(queue[0])
+		queue = queue[1:]
+
+		errs = append(errs, check.errs...)
+		for _, r := range check.related {
+			if !visited[r] {
+				visited[r] = true
+				queue = append(queue, r)
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func checkModel(t reflect.Type) *modelCheck {
+	if cached, ok := modelChecks.Load(t); ok {
+		return cached.(*modelCheck)
+	}
+
+	check := &modelCheck{}
+	check.errs, check.related = validateModelFields(t)
+	modelChecks.Store(t, check)
+	return check
+}
+
+// validateModelFields checks the fields of t and returns the struct types of
+// its relations.
+func validateModelFields(t reflect.Type) (ModelErrors, []reflect.Type) {
+	errs := ModelErrors{}
+	related := []reflect.Type{}
+	fail := func(field, format string, args ...interface{}) {
+		errs = append(errs, &ModelError{Model: t, Field: field, Reason: fmt.Sprintf(format, args...)})
+	}
+
+	var primary, clientID, lid string
+	members := map[string]string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		f, err := parseStructField(i, sf)
+		if err != nil {
+			fail(sf.Name, "bad jsonapi struct tag %q", sf.Tag.Get(annotationJSONAPI))
+			continue
+		}
+		if f == nil {
+			continue
+		}
+
+		switch f.Annotation {
+		case annotationPrimary:
+			if primary != "" {
+				fail(f.Name, "duplicates the %q field %s", annotationPrimary, primary)
+			}
+			primary = f.Name
+			if !isValidMemberName(f.Key) {
+				fail(f.Name, "resource type %q is not a legal member name", f.Key)
+			}
+			if !isPrimaryKind(derefType(f.Type).Kind()) {
+				fail(f.Name, "primary key of type %s is not a string or an integer", f.Type)
+			}
+			validateOptions(f, fail)
+
+		case annotationClientID, annotationLID:
+			seen := &clientID
+			if f.Annotation == annotationLID {
+				seen = &lid
+			}
+			if *seen != "" {
+				fail(f.Name, "duplicates the %q field %s", f.Annotation, *seen)
+			}
+			*seen = f.Name
+			if f.Type.Kind() != reflect.String {
+				fail(f.Name, "%q field of type %s is not a string", f.Annotation, f.Type)
+			}
+
+		case annotationAttribute, annotationRelation:
+			kind := "attribute"
+			reserved := isReservedAttributeName(f.Key)
+			if f.Annotation == annotationRelation {
+				kind = "relationship"
+				reserved = isReservedFieldName(f.Key)
+			}
+
+			switch {
+			case !isValidMemberName(f.Key):
+				fail(f.Name, "%s name %q is not a legal member name", kind, f.Key)
+			case reserved:
+				fail(f.Name, "%s name %q is reserved", kind, f.Key)
+			}
+			if other, exists := members[f.Key]; exists {
+				fail(f.Name, "%s name %q is already used by field %s", kind, f.Key, other)
+			} else {
+				members[f.Key] = f.Name
+			}
+			validateOptions(f, fail)
+
+			if f.Annotation == annotationRelation {
+				if !isRelationType(f.Type) {
+					fail(f.Name, "relation of type %s is not a struct pointer or a slice of struct pointers", f.Type)
+					continue
+				}
+				related = append(related, f.relatedType())
+			}
+
+		default:
+			fail(f.Name, "unknown annotation %q", f.Annotation)
+		}
+	}
+
+	if primary == "" {
+		fail("", "has no %q annotated field", annotationPrimary)
+	}
+
+	return errs, related
+}
+
+// validateOptions reports the options of f that its annotation doesn't
+// support.
+func validateOptions(f *structField, fail func(field, format string, args ...interface{})) {
+	for _, o := range f.Options {
+		switch {
+		case f.Annotation == annotationAttribute &&
+			(o == annotationOmitEmpty || o == annotationISO8601 || o == annotationRFC3339):
+		case f.Annotation == annotationRelation && o == annotationOmitEmpty:
+		default:
+			fail(f.Name, "unknown %q option %q", f.Annotation, o)
+		}
+	}
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
+
+func isPrimaryKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func isRelationType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct
+}
@@ -0,0 +1,102 @@
+package jsonapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type invalidMembers struct {
+	ID        string      `jsonapi:"primary,invalid/members"`
+	Type      string      `jsonapi:"attr,type"`
+	Links     string      `jsonapi:"attr,links"`
+	Private   string      `jsonapi:"attr,_private"`
+	Title     string      `jsonapi:"attr,title,omitempty,upper"`
+	Heading   string      `jsonapi:"attr,title"`
+	Author    *Author     `jsonapi:"relation,title"`
+	Owner     Author      `jsonapi:"relation,owner"`
+	LID       int         `jsonapi:"lid"`
+	Extra     string      `jsonapi:"attribute,extra"`
+	Reviewers []*Reviewer `jsonapi:"relation,reviewers"`
+}
+
+type Author struct {
+	ID    string  `jsonapi:"primary,authors"`
+	Books []*Book `jsonapi:"relation,books"`
+	Name  string  `jsonapi:"attr,name"`
+}
+
+type Reviewer struct {
+	ID   float64 `jsonapi:"primary,reviewers"`
+	Name string  `jsonapi:"attr,id"`
+}
+
+func TestValidateModel_valid(t *testing.T) {
+	for _, model := range []interface{}{
+		new(Blog), []*Post{}, Comment{}, new(Book), new(Company), new(WithPointer),
+		new(TimestampModel), reflect.TypeOf(Author{}), new(CustomAttributeTypes),
+	} {
+		if err := ValidateModel(model); err != nil {
+			t.Errorf("%T: got %v, want nil", model, err)
+		}
+	}
+}
+
+func TestValidateModel_violations(t *testing.T) {
+	err := ValidateModel(new(invalidMembers))
+	errs, ok := err.(ModelErrors)
+	if !ok {
+		t.Fatalf("got %v, want ModelErrors", err)
+	}
+
+	got := []string{}
+	for _, e := range errs {
+		got = append(got, e.Error())
+	}
+	want := []string{
+		`jsonapi: jsonapi.invalidMembers.ID: resource type "invalid/members" is not a legal member name`,
+		`jsonapi: jsonapi.invalidMembers.Type: attribute name "type" is reserved`,
+		`jsonapi: jsonapi.invalidMembers.Links: attribute name "links" is reserved`,
+		`jsonapi: jsonapi.invalidMembers.Private: attribute name "_private" is not a legal member name`,
+		`jsonapi: jsonapi.invalidMembers.Title: unknown "attr" option "upper"`,
+		`jsonapi: jsonapi.invalidMembers.Heading: attribute name "title" is already used by field Title`,
+		`jsonapi: jsonapi.invalidMembers.Author: relationship name "title" is already used by field Title`,
+		`jsonapi: jsonapi.invalidMembers.Owner: relation of type jsonapi.Author is not a struct pointer or a slice of struct pointers`,
+		`jsonapi: jsonapi.invalidMembers.LID: "lid" field of type int is not a string`,
+		`jsonapi: jsonapi.invalidMembers.Extra: unknown annotation "attribute"`,
+		`jsonapi: jsonapi.Reviewer.ID: primary key of type float64 is not a string or an integer`,
+		`jsonapi: jsonapi.Reviewer.Name: attribute name "id" is reserved`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateModel_badTags(t *testing.T) {
+	err := ValidateModel(new(BadModel))
+	want := `jsonapi: jsonapi.BadModel.ID: bad jsonapi struct tag "primary"; jsonapi: jsonapi.BadModel: has no "primary" annotated field`
+	if err == nil || err.Error() != want {
+		t.Fatalf("got %v, want %s", err, want)
+	}
+
+	if err := ValidateModel("posts"); err != ErrUnexpectedType {
+		t.Fatalf("got %v, want %v", err, ErrUnexpectedType)
+	}
+}
+
+func TestValidateModel_cached(t *testing.T) {
+	ValidateModel(new(Reviewer))
+	first, _ := modelChecks.Load(reflect.TypeOf(Reviewer{}))
+	ValidateModel(new(Reviewer))
+	second, _ := modelChecks.Load(reflect.TypeOf(Reviewer{}))
+	if first == nil || first != second {
+		t.Fatal("want the checks of a type to be cached")
+	}
+}
+
+func TestDispatcher_handleInvalidModel(t *testing.T) {
+	err := NewDispatcher().Handle(new(Reviewer), nil)
+	if _, ok := err.(ModelErrors); !ok {
+		t.Fatalf("got %v, want ModelErrors", err)
+	}
+}
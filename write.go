@@ -0,0 +1,225 @@
+package jsonapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const headerLocation = "Location"
+
+// WriteOption configures the response written by WriteResource and
+// WritePayload.
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	withoutIncluded bool
+	sortIncluded    bool
+	etag            bool
+	validateLinkage bool
+	header          http.Header
+}
+
+// WithoutIncluded omits the "included" array from the response, like
+// MarshalPayloadWithoutIncluded.
+func WithoutIncluded() WriteOption {
+	return func(c *writeConfig) {
+		c.withoutIncluded = true
+	}
+}
+
+// WithSortedIncluded sorts the "included" array by type and id, see
+// SortIncluded.
+func WithSortedIncluded() WriteOption {
+	return func(c *writeConfig) {
+		c.sortIncluded = true
+	}
+}
+
+// WithHeader sets a response header, e.g. Location for 201 Created.
+func WithHeader(key, value string) WriteOption {
+	return func(c *writeConfig) {
+		c.header.Set(key, value)
+	}
+}
+
+// WithLocation sets the Location header of a 201 Created response.
+func WithLocation(location string) WriteOption {
+	return WithHeader(headerLocation, location)
+}
+
+// WriteResource writes a jsonapi response for one or many records, see
+// MarshalPayload, with the given status and the Content-Type header set to
+// MediaType.
+//
+// The payload is marshalled before anything is written so that, should
+// marshalling fail, a 500 Internal Server Error document can be written
+// instead; the marshalling error is then returned.
+//
+//	func CreateBlog(w http.ResponseWriter, r *http.Request) {
+//		blog := new(Blog)
+//
+//		// ...unmarshal and save your blog...
+//
+//		jsonapi.WriteResource(w, http.StatusCreated, blog,
+//			jsonapi.WithLocation(fmt.Sprintf("/blogs/%d", blog.ID)))
+//	}
+func WriteResource(w http.ResponseWriter, status int, models interface{}, opts ...WriteOption) error {
+	payload, err := Marshal(models)
+	if err != nil {
+		return writeInternalError(w, err)
+	}
+
+	return WritePayload(w, status, payload, opts...)
+}
+
+// WritePayload writes a payload returned by Marshal, e.g. after it has been
+// post-processed, the same way WriteResource does.
+func WritePayload(w http.ResponseWriter, status int, payload Payloader, opts ...WriteOption) error {
+	c := &writeConfig{header: http.Header{}}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.withoutIncluded {
+		payload.clearIncluded()
+	}
+	if c.sortIncluded {
+		SortIncluded(payload)
+	}
+	if c.validateLinkage {
+		if errs := ValidateLinkage(payload); errs != nil {
+			for _, e := range errs {
+				e.Status = strconv.Itoa(http.StatusInternalServerError)
+			}
+			WriteErrors(w, http.StatusInternalServerError, errs)
+			return ErrFullLinkage
+		}
+	}
+	if c.etag {
+		tag, err := ETag(payload)
+		if err != nil {
+			return writeInternalError(w, err)
+		}
+		c.header.Set(headerETag, tag)
+	}
+
+	return writePayload(w, status, payload, c.header)
+}
+
+// WriteErrors writes a jsonapi errors response, see MarshalErrors, with the
+// Content-Type header set to MediaType. If status is 0 the response status is
+// computed from the Status of the error objects, see ErrorsStatus.
+func WriteErrors(w http.ResponseWriter, status int, errorObjects []*ErrorObject) error {
+	if status == 0 {
+		status = ErrorsStatus(errorObjects)
+	}
+
+	return writePayload(w, status, &ErrorsPayload{Errors: errorObjects}, nil)
+}
+
+// WriteNoContent writes a 204 No Content response, which has no body and
+// therefore no Content-Type.
+func WriteNoContent(w http.ResponseWriter) {
+	w.Header().Del(headerContentType)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ErrorsStatus returns the most generally applicable HTTP status code for a
+// list of error objects: their common Status if they all agree, 400 Bad
+// Request if they are all client errors and 500 Internal Server Error
+// otherwise.
+//
+// http://jsonapi.org/format/#errors-processing
+func ErrorsStatus(errorObjects []*ErrorObject) int {
+	status := 0
+
+	for _, e := range errorObjects {
+		s, err := strconv.Atoi(e.Status)
+		if err != nil || s < 400 || s > 599 {
+			return http.StatusInternalServerError
+		}
+
+		switch {
+		case status == 0 || status == s:
+			status = s
+		case status < 500 && s < 500:
+			status = http.StatusBadRequest
+		default:
+			return http.StatusInternalServerError
+		}
+	}
+
+	if status == 0 {
+		return http.StatusInternalServerError
+	}
+	return status
+}
+
+func writePayload(w http.ResponseWriter, status int, payload interface{}, header http.Header) error {
+	buf := bytes.NewBuffer(nil)
+	if err := json.NewEncoder(buf).Encode(payload); err != nil {
+		if _, isErrors := payload.(*ErrorsPayload); isErrors {
+			// Nothing sensible is left to encode, fall back on plain text
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return err
+		}
+		return writeInternalError(w, err)
+	}
+
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	setContentType(w)
+	w.WriteHeader(status)
+
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// writeInternalError writes err as a 500 Internal Server Error document and
+// returns it.
+func writeInternalError(w http.ResponseWriter, err error) error {
+	writePayload(w, http.StatusInternalServerError, &ErrorsPayload{
+		Errors: []*ErrorObject{{
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: err.Error(),
+			Status: strconv.Itoa(http.StatusInternalServerError),
+		}},
+	}, nil)
+
+	return err
+}
+
+// setContentType sets the Content-Type header to MediaType unless the handler
+// has set one, or w, or a writer it wraps, sets it itself: the
+// ContentNegotiator sets the negotiated one, and responses buffered by
+// Conditional get theirs when they are flushed, from the writer they are
+// flushed to. Wrapped writers are found through an Unwrap method, as with
+// http.ResponseController.
+func setContentType(w http.ResponseWriter) {
+	for rw := w; ; {
+		if cw, ok := rw.(contentTypeWriter); ok {
+			cw.useDefaultContentType()
+			return
+		}
+		wrapper, ok := rw.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			break
+		}
+		rw = wrapper.Unwrap()
+	}
+	if w.Header().Get(headerContentType) == "" {
+		w.Header().Set(headerContentType, MediaType)
+	}
+}
+
+// contentTypeWriter is implemented by the response writers setting the
+// Content-Type header of jsonapi responses themselves.
+type contentTypeWriter interface {
+	http.ResponseWriter
+	// useDefaultContentType is called instead of setting the default
+	// Content-Type header of jsonapi responses.
+	useDefaultContentType()
+}
@@ -0,0 +1,198 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteResource(t *testing.T) {
+	rr := httptest.NewRecorder()
+	if err := WriteResource(rr, http.StatusCreated, testBlog(), WithLocation("/blogs/5")); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rr.Code, http.StatusCreated; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got, want := rr.Header().Get(headerContentType), MediaType; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+	if got, want := rr.Header().Get(headerLocation), "/blogs/5"; got != want {
+		t.Fatalf("got Location %q, want %q", got, want)
+	}
+
+	payload := new(OnePayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Data.Type != "blogs" || payload.Data.ID != "5" {
+		t.Fatalf("got data %s/%s, want blogs/5", payload.Data.Type, payload.Data.ID)
+	}
+	if len(payload.Included) == 0 {
+		t.Fatal("Expected included resources")
+	}
+}
+
+func TestWriteResource_withoutIncluded(t *testing.T) {
+	rr := httptest.NewRecorder()
+	if err := WriteResource(rr, http.StatusOK, []*Blog{testBlog()}, WithoutIncluded()); err != nil {
+		t.Fatal(err)
+	}
+
+	payload := new(ManyPayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Included) != 0 {
+		t.Fatalf("got %d included resources, want none", len(payload.Included))
+	}
+}
+
+func TestWriteResource_marshalError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	if err := WriteResource(rr, http.StatusOK, &BadComment{ID: 1}); err == nil {
+		t.Fatal("Was expecting an error")
+	}
+
+	if got, want := rr.Code, http.StatusInternalServerError; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got, want := rr.Header().Get(headerContentType), MediaType; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+
+	payload := new(ErrorsPayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Errors) != 1 || payload.Errors[0].Status != "500" {
+		t.Fatalf("got errors %v, want a single 500 error", payload.Errors)
+	}
+}
+
+func TestWriteResource_negotiatedContentType(t *testing.T) {
+	handler := (&ContentNegotiator{Extensions: []string{testExtension}}).Handler(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			WriteResource(w, http.StatusOK, testBlog())
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodGet, "/blogs/5", nil)
+	r.Header.Set(headerAccept, MediaType+`; ext="`+testExtension+`"`)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, r)
+
+	if got, want := rr.Header().Get(headerContentType), MediaType+`; ext="`+testExtension+`"`; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+}
+
+// statusRecorder is a middleware's ResponseWriter, recording the status.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestWriteResource_wrappedWriter(t *testing.T) {
+	write := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w}
+		WriteResource(recorder, http.StatusOK, &Blog{ID: 1})
+	})
+
+	for name, test := range map[string]struct {
+		handler http.Handler
+		want    string
+	}{
+		"negotiated":  {(&ContentNegotiator{Extensions: []string{testExtension}}).Handler(write), MediaType + `; ext="` + testExtension + `"`},
+		"conditional": {(&ContentNegotiator{Extensions: []string{testExtension}}).Handler(Conditional(write)), MediaType + `; ext="` + testExtension + `"`},
+		"default":     {write, MediaType},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/blogs/1", nil)
+		r.Header.Set(headerAccept, MediaType+`; ext="`+testExtension+`"`)
+		rr := httptest.NewRecorder()
+		test.handler.ServeHTTP(rr, r)
+
+		if got := rr.Header().Get(headerContentType); got != test.want {
+			t.Errorf("%s: got Content-Type %q, want %q", name, got, test.want)
+		}
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	rr := httptest.NewRecorder()
+	errs := []*ErrorObject{
+		{Title: "Invalid Attribute", Status: "422"},
+		{Title: "Not Found", Status: "404"},
+	}
+	if err := WriteErrors(rr, 0, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := rr.Code, http.StatusBadRequest; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got, want := rr.Header().Get(headerContentType), MediaType; got != want {
+		t.Fatalf("got Content-Type %q, want %q", got, want)
+	}
+
+	payload := new(ErrorsPayload)
+	if err := json.NewDecoder(rr.Body).Decode(payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(payload.Errors) != 2 {
+		t.Fatalf("got %d errors, want 2", len(payload.Errors))
+	}
+}
+
+func TestWriteNoContent(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set(headerContentType, MediaType)
+	WriteNoContent(rr)
+
+	if got, want := rr.Code, http.StatusNoContent; got != want {
+		t.Fatalf("got status %d, want %d", got, want)
+	}
+	if got := rr.Header().Get(headerContentType); got != "" {
+		t.Fatalf("got Content-Type %q, want none", got)
+	}
+	if rr.Body.Len() != 0 {
+		t.Fatalf("got body %q, want none", rr.Body.String())
+	}
+}
+
+func TestErrorsStatus(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		statuses []string
+		want     int
+	}{
+		{desc: "none", want: http.StatusInternalServerError},
+		{desc: "single", statuses: []string{"404"}, want: http.StatusNotFound},
+		{desc: "same", statuses: []string{"422", "422"}, want: http.StatusUnprocessableEntity},
+		{desc: "client errors", statuses: []string{"422", "409"}, want: http.StatusBadRequest},
+		{desc: "server errors", statuses: []string{"502", "503"}, want: http.StatusInternalServerError},
+		{desc: "mixed", statuses: []string{"400", "503"}, want: http.StatusInternalServerError},
+		{desc: "missing status", statuses: []string{"400", ""}, want: http.StatusInternalServerError},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			var errs []*ErrorObject
+			for _, s := range tc.statuses {
+				errs = append(errs, &ErrorObject{Status: s})
+			}
+			if got := ErrorsStatus(errs); got != tc.want {
+				t.Fatalf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+}